@@ -0,0 +1,253 @@
+package str
+
+import (
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultChunkSize is the size of the chunks allocated by a Builder
+// without a ChunkPool.
+const defaultChunkSize = 4 << 10
+
+// ChunkPool recycles the memory chunks of Builders, so that builders
+// created and reset for every request share a steady set of chunks.
+// A ChunkPool is safe for concurrent use by multiple goroutines.
+type ChunkPool struct {
+	size int
+	pool sync.Pool
+}
+
+// NewChunkPool returns a new ChunkPool of chunks of chunkSize bytes.
+func NewChunkPool(chunkSize int) *ChunkPool {
+	return &ChunkPool{size: chunkSize}
+}
+
+func (p *ChunkPool) get() *[]byte {
+	if c, ok := p.pool.Get().(*[]byte); ok {
+		*c = (*c)[:0]
+		return c
+	}
+	c := make([]byte, 0, p.size)
+	return &c
+}
+
+func (p *ChunkPool) put(c *[]byte) {
+	if cap(*c) != p.size {
+		// Oversized chunk, allocated for a single long string.
+		return
+	}
+	p.pool.Put(c)
+}
+
+// Builder builds Str values in large memory chunks, so that building many
+// strings costs a handful of allocations rather than one per string.
+//
+// Bytes written to the Builder accumulate into a pending string, which
+// [Builder.Finish] returns as a Str and which is never moved afterwards.
+// All Str values returned by the Builder remain valid until [Builder.Reset].
+//
+// The zero value is ready to use. A Builder must not be copied after
+// first use and is not safe for concurrent use.
+type Builder struct {
+	pool *ChunkPool
+	// chunks holds all chunks owned by the builder, the first used of
+	// them are in use and chunks[used-1] is being filled.
+	chunks []*[]byte
+	used   int
+	// start is the offset of the pending string in chunks[used-1].
+	start int
+}
+
+// NewBuilder returns a new Builder taking its chunks from pool.
+// If pool is nil, chunks are allocated and retained by the Builder.
+func NewBuilder(pool *ChunkPool) *Builder {
+	return &Builder{pool: pool}
+}
+
+// chunkSize returns the size of regular chunks.
+func (b *Builder) chunkSize() int {
+	if b.pool != nil {
+		return b.pool.size
+	}
+	return defaultChunkSize
+}
+
+// chunk returns the chunk being filled. There must be one.
+func (b *Builder) chunk() *[]byte {
+	return b.chunks[b.used-1]
+}
+
+// Grow ensures that n more bytes can be written to the pending string
+// without moving it to another chunk.
+func (b *Builder) Grow(n int) {
+	var pending []byte
+	if b.used > 0 {
+		c := b.chunk()
+		if cap(*c)-len(*c) >= n {
+			return
+		}
+		pending = (*c)[b.start:]
+		*c = (*c)[:b.start]
+	}
+
+	// The pending string moves to the next chunk, which is either left
+	// over from before Reset or newly allocated.
+	need := len(pending) + n
+	if b.used < len(b.chunks) && cap(*b.chunks[b.used]) >= need {
+		*b.chunks[b.used] = (*b.chunks[b.used])[:0]
+	} else {
+		var c *[]byte
+		if b.pool != nil && need <= b.pool.size {
+			c = b.pool.get()
+		} else {
+			buf := make([]byte, 0, max(need, b.chunkSize()))
+			c = &buf
+		}
+		b.chunks = append(b.chunks, nil)
+		copy(b.chunks[b.used+1:], b.chunks[b.used:])
+		b.chunks[b.used] = c
+	}
+	b.used++
+	c := b.chunk()
+	*c = append(*c, pending...)
+	b.start = 0
+}
+
+// Len returns the length of the pending string.
+func (b *Builder) Len() int {
+	if b.used == 0 {
+		return 0
+	}
+	return len(*b.chunk()) - b.start
+}
+
+// Write appends the contents of p to the pending string.
+// It always returns len(p), nil.
+func (b *Builder) Write(p []byte) (int, error) {
+	b.Grow(len(p))
+	c := b.chunk()
+	*c = append(*c, p...)
+	return len(p), nil
+}
+
+// WriteString appends the contents of s to the pending string.
+// It always returns len(s), nil.
+func (b *Builder) WriteString(s string) (int, error) {
+	b.Grow(len(s))
+	c := b.chunk()
+	*c = append(*c, s...)
+	return len(s), nil
+}
+
+// WriteStr appends the contents of s to the pending string.
+func (b *Builder) WriteStr(s Str) {
+	b.Write(s.asBytes())
+}
+
+// WriteByte appends the byte c to the pending string.
+// It always returns nil.
+func (b *Builder) WriteByte(c byte) error {
+	b.Grow(1)
+	p := b.chunk()
+	*p = append(*p, c)
+	return nil
+}
+
+// WriteRune appends the UTF-8 encoding of Unicode code point r to the
+// pending string. It returns the length of r and a nil error.
+func (b *Builder) WriteRune(r rune) (int, error) {
+	b.Grow(utf8.UTFMax)
+	c := b.chunk()
+	n := len(*c)
+	*c = utf8.AppendRune(*c, r)
+	return len(*c) - n, nil
+}
+
+// WriteInt appends the string form of i in the given base to the pending string.
+func (b *Builder) WriteInt(i int64, base int) {
+	var buf [65]byte // sign and 64 binary digits
+	b.Write(strconv.AppendInt(buf[:0], i, base))
+}
+
+// WriteUint appends the string form of u in the given base to the pending string.
+func (b *Builder) WriteUint(u uint64, base int) {
+	var buf [64]byte
+	b.Write(strconv.AppendUint(buf[:0], u, base))
+}
+
+// WriteFloat appends the string form of the floating-point number f,
+// as formatted by [strconv.FormatFloat], to the pending string.
+func (b *Builder) WriteFloat(f float64, fmt byte, prec, bitSize int) {
+	var buf [32]byte
+	b.Write(strconv.AppendFloat(buf[:0], f, fmt, prec, bitSize))
+}
+
+// Finish returns the pending string and starts a new, empty one.
+func (b *Builder) Finish() Str {
+	if b.Len() == 0 {
+		return empty
+	}
+
+	c := b.chunk()
+	s := NewFromBytes((*c)[b.start:])
+	b.start = len(*c)
+	return s
+}
+
+// Clone returns a copy of s held by the Builder. The pending string must be empty.
+func (b *Builder) Clone(s Str) Str {
+	b.WriteStr(s)
+	return b.Finish()
+}
+
+// build returns the string appended by f to an empty buffer with room for
+// at least n bytes. The buffer is the pending string of b, or newly allocated
+// if b is nil. f may append more than n bytes: it moves the chunk contents,
+// but the strings finished so far keep their memory.
+func build(b *Builder, n int, f func([]byte) []byte) Str {
+	if b == nil {
+		return NewFromBytes(f(make([]byte, 0, n)))
+	}
+
+	b.Grow(n)
+	c := b.chunk()
+	*c = f(*c)
+	return b.Finish()
+}
+
+// tryBuild is like build for f which may fail, in which case it returns
+// the error and leaves the pending string of b as it was.
+func tryBuild(b *Builder, n int, f func([]byte) ([]byte, error)) (Str, error) {
+	if b == nil {
+		dst, err := f(make([]byte, 0, n))
+		if err != nil {
+			return empty, err
+		}
+		return NewFromBytes(dst), nil
+	}
+
+	b.Grow(n)
+	c := b.chunk()
+	dst, err := f(*c)
+	if err != nil {
+		return empty, err
+	}
+	*c = dst
+	return b.Finish(), nil
+}
+
+// Reset discards the pending string and all strings built so far, so their
+// memory can be reused. Str values returned by the Builder must not be used
+// after Reset. With a ChunkPool, the chunks are returned to the pool,
+// otherwise the Builder keeps them for the strings built next.
+func (b *Builder) Reset() {
+	if b.pool != nil {
+		for _, c := range b.chunks {
+			b.pool.put(c)
+		}
+		clear(b.chunks)
+		b.chunks = b.chunks[:0]
+	}
+	b.used, b.start = 0, 0
+}
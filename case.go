@@ -0,0 +1,250 @@
+package str
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Map returns s with all its characters modified according to the mapping
+// function. If mapping returns a negative value, the character is dropped
+// from the string with no replacement. Invalid UTF-8 sequences are
+// replaced by U+FFFD.
+//
+// If no character changes, s itself is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func Map(mapping func(rune) rune, s Str, b *Builder) Str {
+	i := mapIndex(mapping, s)
+	if i < 0 {
+		return s
+	}
+
+	return build(b, s.Len+utf8.UTFMax, func(dst []byte) []byte {
+		str := s.String()
+		dst = append(dst, str[:i]...)
+		for _, c := range str[i:] {
+			if r := mapping(c); r >= 0 {
+				dst = utf8.AppendRune(dst, r)
+			}
+		}
+		return dst
+	})
+}
+
+// mapIndex returns the index of the first character of s changed by
+// mapping, or -1 if Map would return s unchanged.
+func mapIndex(mapping func(rune) rune, s Str) int {
+	str := s.String()
+	for i, c := range str {
+		r := mapping(c)
+		if r == c && c != utf8.RuneError {
+			continue
+		}
+
+		if c == utf8.RuneError {
+			// Output is the same as input for a genuine U+FFFD,
+			// but invalid UTF-8 has to be replaced.
+			if _, width := utf8.DecodeRuneInString(str[i:]); width != 1 && r == c {
+				continue
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// ToUpper returns s with all Unicode letters mapped to their upper case.
+// If s is already upper case, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func ToUpper(s Str, b *Builder) Str {
+	if isASCII, hasLower := asciiCase(s, 'a', 'z'); isASCII {
+		if !hasLower {
+			return s
+		}
+		return mapASCII(s, b, 'a', 'z')
+	}
+	return Map(unicode.ToUpper, s, b)
+}
+
+// ToLower returns s with all Unicode letters mapped to their lower case.
+// If s is already lower case, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func ToLower(s Str, b *Builder) Str {
+	if isASCII, hasUpper := asciiCase(s, 'A', 'Z'); isASCII {
+		if !hasUpper {
+			return s
+		}
+		return mapASCII(s, b, 'A', 'Z')
+	}
+	return Map(unicode.ToLower, s, b)
+}
+
+// ToTitle returns s with all Unicode letters mapped to their Unicode title case.
+// If s is already title case, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func ToTitle(s Str, b *Builder) Str {
+	if isASCII, hasLower := asciiCase(s, 'a', 'z'); isASCII {
+		// ASCII title case is upper case.
+		if !hasLower {
+			return s
+		}
+		return mapASCII(s, b, 'a', 'z')
+	}
+	return Map(unicode.ToTitle, s, b)
+}
+
+// asciiCase reports whether s is ASCII and whether it has bytes in [lo, hi].
+func asciiCase(s Str, lo, hi byte) (isASCII, has bool) {
+	for _, c := range s.asBytes() {
+		if c >= utf8.RuneSelf {
+			return false, has
+		}
+		has = has || lo <= c && c <= hi
+	}
+	return true, has
+}
+
+// mapASCII returns ASCII s with the letters in [lo, hi] switched to the other case.
+func mapASCII(s Str, b *Builder, lo, hi byte) Str {
+	return build(b, s.Len, func(dst []byte) []byte {
+		for _, c := range s.asBytes() {
+			if lo <= c && c <= hi {
+				c ^= 'a' - 'A'
+			}
+			dst = append(dst, c)
+		}
+		return dst
+	})
+}
+
+// equalFoldRune reports whether sr and tr are equal under simple Unicode case-folding.
+func equalFoldRune(sr, tr rune) bool {
+	// Easy case.
+	if tr == sr {
+		return true
+	}
+
+	// Make sr < tr to simplify what follows.
+	if tr < sr {
+		tr, sr = sr, tr
+	}
+	// Fast check for ASCII.
+	if tr < utf8.RuneSelf {
+		// ASCII only, sr/tr must be upper/lower case
+		return 'A' <= sr && sr <= 'Z' && tr == sr+'a'-'A'
+	}
+
+	// General case. SimpleFold(x) returns the next equivalent rune > x
+	// or wraps around to smaller values.
+	r := unicode.SimpleFold(sr)
+	for r != sr && r < tr {
+		r = unicode.SimpleFold(r)
+	}
+	return r == tr
+}
+
+// decodeRune is utf8.DecodeRuneInString with a fast path for ASCII.
+func decodeRune(s string) (rune, int) {
+	if c := s[0]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRuneInString(s)
+}
+
+// decodeLastRune is utf8.DecodeLastRuneInString with a fast path for ASCII.
+func decodeLastRune(s string) (rune, int) {
+	if c := s[len(s)-1]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeLastRuneInString(s)
+}
+
+// prefixFold reports whether s begins with prefix under simple Unicode
+// case-folding, and if so the length of the matching prefix of s.
+func prefixFold(s, prefix string) (n int, ok bool) {
+	for len(prefix) > 0 {
+		if n == len(s) {
+			return 0, false
+		}
+
+		sr, ss := decodeRune(s[n:])
+		pr, ps := decodeRune(prefix)
+		if !equalFoldRune(sr, pr) {
+			return 0, false
+		}
+		n += ss
+		prefix = prefix[ps:]
+	}
+	return n, true
+}
+
+// EqualFold reports whether s and t, interpreted as UTF-8 strings,
+// are equal under simple Unicode case-folding, which is a more general
+// form of case-insensitivity.
+func EqualFold(s, t Str) bool {
+	// ASCII fast path
+	i := 0
+	for ; i < s.Len && i < t.Len; i++ {
+		sr, tr := s.Get(i), t.Get(i)
+		if sr|tr >= utf8.RuneSelf {
+			n, ok := prefixFold(s.SliceFrom(i).String(), t.SliceFrom(i).String())
+			return ok && i+n == s.Len
+		}
+		if !equalFoldRune(rune(sr), rune(tr)) {
+			return false
+		}
+	}
+	// Check if we've exhausted both strings.
+	return s.Len == t.Len
+}
+
+// HasPrefixFold reports whether s begins with prefix
+// under simple Unicode case-folding.
+func HasPrefixFold(s, prefix Str) bool {
+	_, ok := prefixFold(s.String(), prefix.String())
+	return ok
+}
+
+// HasSuffixFold reports whether s ends with suffix
+// under simple Unicode case-folding.
+func HasSuffixFold(s, suffix Str) bool {
+	str, suf := s.String(), suffix.String()
+	for len(suf) > 0 {
+		if len(str) == 0 {
+			return false
+		}
+
+		sr, ss := decodeLastRune(str)
+		tr, ts := decodeLastRune(suf)
+		if !equalFoldRune(sr, tr) {
+			return false
+		}
+		str, suf = str[:len(str)-ss], suf[:len(suf)-ts]
+	}
+	return true
+}
+
+// IndexFold returns the index of the first instance of substr in s under
+// simple Unicode case-folding, or -1 if substr is not present in s.
+func IndexFold(s, substr Str) int {
+	if substr.Len == 0 {
+		return 0
+	}
+
+	str, sub := s.String(), substr.String()
+	// Candidates are the runes of s folding to the first rune of substr.
+	first, size := decodeRune(sub)
+	for i := 0; i < len(str); {
+		r, n := decodeRune(str[i:])
+		if equalFoldRune(r, first) {
+			if _, ok := prefixFold(str[i+n:], sub[size:]); ok {
+				return i
+			}
+		}
+		i += n
+	}
+	return -1
+}
@@ -0,0 +1,373 @@
+// Package csv reads comma-separated values files, as defined by RFC 4180,
+// with the semantics of package encoding/csv, returning the fields as views
+// into the input rather than as newly allocated strings.
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rprtr258/str"
+)
+
+// ParseError is returned for parsing errors, with their 1-based line and
+// column. It is the type of package encoding/csv.
+type ParseError = csv.ParseError
+
+// The errors that can be returned in ParseError.Err, those of package encoding/csv.
+var (
+	ErrBareQuote  = csv.ErrBareQuote
+	ErrQuote      = csv.ErrQuote
+	ErrFieldCount = csv.ErrFieldCount
+)
+
+var errInvalidDelim = errors.New("csv: invalid field or comment delimiter")
+
+// validDelim reports whether r can be a field or comment delimiter.
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// Reader reads records from a CSV-encoded Str or io.Reader.
+//
+// Its options and the syntax it accepts are those of [csv.Reader]: each
+// record is a line of fields separated by Comma, "\r\n" line endings are
+// read as "\n", empty lines are skipped, and fields starting with a quote
+// extend up to the closing quote, over line breaks, with doubled quotes
+// standing for quotes. Set Comma to '\t' to read TSV files.
+//
+// The fields of a record are views into the input, unquoted, except for
+// quoted fields which have doubled quotes or "\r\n" line breaks, whose
+// values are built in a buffer reused by the next call to Read. When reading
+// from an io.Reader, the input itself is held in a buffer reused by the next
+// call to Read.
+type Reader struct {
+	// Comma is the field delimiter, set to ',' by NewReader.
+	// It must be a valid rune other than \r, \n, " and U+FFFD.
+	Comma rune
+	// Comment, if not 0, is the comment character. Lines beginning with
+	// it are skipped.
+	Comment rune
+	// FieldsPerRecord is the number of expected fields per record. If it is
+	// positive, Read returns ErrFieldCount for records with another number
+	// of fields. If it is 0, it is set to the number of fields of the first
+	// record. If it is negative, records may have any number of fields.
+	FieldsPerRecord int
+	// LazyQuotes allows quotes in unquoted fields, and non-doubled quotes
+	// in quoted fields.
+	LazyQuotes bool
+	// TrimLeadingSpace makes the leading white space of fields ignored.
+	TrimLeadingSpace bool
+
+	// input holds the input left to read when reading from a Str,
+	// and rd the input otherwise.
+	input str.Str
+	rd    *bufio.Reader
+	// raw holds the lines of the record being read from rd.
+	raw []byte
+	// text is the input from the start of the record being read.
+	text    str.Str
+	numLine int
+
+	// buf holds the values of the fields which are not views of the input.
+	buf       []byte
+	fields    []field
+	positions []position
+	record    []str.Str
+}
+
+// field locates the value of a field, in the text of its record,
+// or in buf if it is buffered.
+type field struct {
+	buffered   bool
+	start, end int
+}
+
+type position struct {
+	line, col int
+}
+
+// NewReader returns a new Reader reading from s.
+func NewReader(s str.Str) *Reader {
+	return &Reader{Comma: ',', input: s}
+}
+
+// NewStreamReader returns a new Reader reading from r, with buffering.
+func NewStreamReader(r io.Reader) *Reader {
+	return &Reader{Comma: ',', rd: bufio.NewReader(r)}
+}
+
+// Read reads one record from the input and returns its fields. The record
+// and its buffered fields are reused by the next call to Read. At the end
+// of the input, Read returns nil, io.EOF. If the record has an unexpected
+// number of fields, Read returns it with a ParseError for ErrFieldCount.
+func (r *Reader) Read() ([]str.Str, error) {
+	return r.readRecord()
+}
+
+// Records returns an iterator over the records of the input and the errors
+// of Read, up to the end of the input, which is not reported.
+// The records are reused by the iterator, as by Read.
+func (r *Reader) Records() iter.Seq2[[]str.Str, error] {
+	return func(yield func([]str.Str, error) bool) {
+		for {
+			record, err := r.Read()
+			if err == io.EOF || !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// FieldPos returns the 1-based line and column of the start of the field
+// with the given index in the record most recently returned by Read.
+// Columns are counted in bytes.
+func (r *Reader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(r.positions) {
+		panic("out of range index passed to FieldPos")
+	}
+	p := &r.positions[field]
+	return p.line, p.col
+}
+
+// startRecord starts reading a record from the next line of the input.
+func (r *Reader) startRecord() {
+	if r.rd == nil {
+		r.text = r.input
+	} else {
+		r.raw = r.raw[:0]
+	}
+}
+
+// readLine reads the next line of the input into the text of the record,
+// and returns it without its line terminator, with its offset in that text,
+// and the length of its terminator, which is 2 for "\r\n". A trailing "\r"
+// at the end of the input is dropped.
+func (r *Reader) readLine() (line str.Str, off, nl int, err error) {
+	if r.rd == nil {
+		if r.input.Len == 0 {
+			return r.input, r.text.Len, 0, io.EOF
+		}
+		off = r.text.Len - r.input.Len
+		n := str.IndexByte(r.input, '\n') + 1
+		if n == 0 {
+			n = r.input.Len
+		}
+		line = r.input.SliceTo(n)
+		r.input = r.input.SliceFrom(n)
+	} else {
+		off = len(r.raw)
+		var chunk []byte
+		chunk, err = r.rd.ReadSlice('\n')
+		r.raw = append(r.raw, chunk...)
+		for err == bufio.ErrBufferFull {
+			chunk, err = r.rd.ReadSlice('\n')
+			r.raw = append(r.raw, chunk...)
+		}
+		r.text = str.NewFromBytes(r.raw)
+		line = r.text.SliceFrom(off)
+		if line.Len > 0 && err == io.EOF {
+			err = nil
+		}
+	}
+
+	r.numLine++
+	n := line.Len
+	switch {
+	case n >= 2 && line.Get(n-2) == '\r' && line.Get(n-1) == '\n':
+		return line.SliceTo(n - 2), off, 2, err
+	case n >= 1 && line.Get(n-1) == '\n':
+		return line.SliceTo(n - 1), off, 1, err
+	case n >= 1 && line.Get(n-1) == '\r':
+		// At the end of the input.
+		return line.SliceTo(n - 1), off, 0, err
+	}
+	return line, off, 0, err
+}
+
+// appendRaw appends the n bytes at off in the text of the record to the
+// value of the field f, which remains a view of the text as long as they
+// follow its value there.
+func (r *Reader) appendRaw(f *field, off, n int) {
+	if !f.buffered && off == f.end {
+		f.end += n
+		return
+	}
+	text := r.text.String()
+	if !f.buffered {
+		start := len(r.buf)
+		r.buf = append(r.buf, text[f.start:f.end]...)
+		*f = field{buffered: true, start: start}
+	}
+	r.buf = append(r.buf, text[off:off+n]...)
+	f.end = len(r.buf)
+}
+
+// readRecord reads a record, as package encoding/csv does.
+func (r *Reader) readRecord() ([]str.Str, error) {
+	if r.Comma == r.Comment || !validDelim(r.Comma) || (r.Comment != 0 && !validDelim(r.Comment)) {
+		return nil, errInvalidDelim
+	}
+
+	// Read a line, skipping empty lines and comments.
+	var line str.Str
+	var off, nl int
+	var errRead error
+	for errRead == nil {
+		r.startRecord()
+		line, off, nl, errRead = r.readLine()
+		if r.Comment != 0 && nextRune(line) == r.Comment {
+			continue
+		}
+		if errRead == nil && line.Len == 0 {
+			continue
+		}
+		break
+	}
+	if errRead == io.EOF {
+		return nil, errRead
+	}
+
+	var err error
+	commaLen := utf8.RuneLen(r.Comma)
+	recLine := r.numLine
+	r.buf = r.buf[:0]
+	r.fields = r.fields[:0]
+	r.positions = r.positions[:0]
+	pos := position{line: r.numLine, col: 1}
+	// skip advances line by n bytes.
+	skip := func(n int) {
+		line = line.SliceFrom(n)
+		off += n
+		pos.col += n
+	}
+parseField:
+	for {
+		if r.TrimLeadingSpace {
+			i := str.IndexFunc(line, func(r rune) bool {
+				return !unicode.IsSpace(r)
+			})
+			if i < 0 {
+				i = line.Len
+			}
+			skip(i)
+		}
+
+		if line.Len == 0 || line.Get(0) != '"' {
+			// An unquoted field.
+			i := str.IndexRune(line, r.Comma)
+			n := i
+			if i < 0 {
+				n = line.Len
+			}
+			if !r.LazyQuotes {
+				if j := str.IndexByte(line.SliceTo(n), '"'); j >= 0 {
+					err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col + j, Err: ErrBareQuote}
+					break parseField
+				}
+			}
+			r.fields = append(r.fields, field{start: off, end: off + n})
+			r.positions = append(r.positions, pos)
+			if i < 0 {
+				break parseField
+			}
+			skip(i + commaLen)
+			continue parseField
+		}
+
+		// A quoted field.
+		fieldPos := pos
+		skip(1)
+		f := field{start: off, end: off}
+		for {
+			if i := str.IndexByte(line, '"'); i >= 0 {
+				r.appendRaw(&f, off, i)
+				skip(i + 1)
+				switch rn := nextRune(line); {
+				case rn == '"':
+					// A doubled quote.
+					r.appendRaw(&f, off, 1)
+					skip(1)
+				case rn == r.Comma:
+					// The end of the field.
+					skip(commaLen)
+					r.fields = append(r.fields, f)
+					r.positions = append(r.positions, fieldPos)
+					continue parseField
+				case line.Len == 0:
+					// The end of the record.
+					r.fields = append(r.fields, f)
+					r.positions = append(r.positions, fieldPos)
+					break parseField
+				case r.LazyQuotes:
+					// A bare quote.
+					r.appendRaw(&f, off-1, 1)
+				default:
+					err = &ParseError{StartLine: recLine, Line: r.numLine, Column: pos.col - 1, Err: ErrQuote}
+					break parseField
+				}
+			} else if line.Len > 0 || nl > 0 {
+				// The end of the line, which continues the field.
+				r.appendRaw(&f, off, line.Len)
+				if nl > 0 {
+					r.appendRaw(&f, off+line.Len+nl-1, 1)
+				}
+				if errRead != nil {
+					break parseField
+				}
+				pos.col += line.Len + min(nl, 1)
+				line, off, nl, errRead = r.readLine()
+				if line.Len > 0 || nl > 0 {
+					pos.line++
+					pos.col = 1
+				}
+				if errRead == io.EOF {
+					errRead = nil
+				}
+			} else {
+				// The end of the input.
+				if !r.LazyQuotes && errRead == nil {
+					err = &ParseError{StartLine: recLine, Line: pos.line, Column: pos.col, Err: ErrQuote}
+					break parseField
+				}
+				r.fields = append(r.fields, f)
+				r.positions = append(r.positions, fieldPos)
+				break parseField
+			}
+		}
+	}
+	if err == nil {
+		err = errRead
+	}
+
+	text, buf := r.text, str.NewFromBytes(r.buf)
+	r.record = r.record[:0]
+	for _, f := range r.fields {
+		if f.buffered {
+			r.record = append(r.record, buf.Slice(f.start, f.end))
+		} else {
+			r.record = append(r.record, text.Slice(f.start, f.end))
+		}
+	}
+
+	// Check or set the expected number of fields per record.
+	if r.FieldsPerRecord > 0 {
+		if len(r.record) != r.FieldsPerRecord && err == nil {
+			err = &ParseError{StartLine: recLine, Line: recLine, Column: 1, Err: ErrFieldCount}
+		}
+	} else if r.FieldsPerRecord == 0 {
+		r.FieldsPerRecord = len(r.record)
+	}
+	return r.record, err
+}
+
+// nextRune returns the first rune of s, or utf8.RuneError if s is empty.
+func nextRune(s str.Str) rune {
+	r, _ := utf8.DecodeRuneInString(s.String())
+	return r
+}
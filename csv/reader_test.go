@@ -0,0 +1,333 @@
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rprtr258/str"
+)
+
+// record is a record read with its error and the positions of its fields.
+type record struct {
+	fields []string
+	pos    [][2]int
+	err    string
+}
+
+func (r record) String() string {
+	return fmt.Sprintf("%q %v %q", r.fields, r.pos, r.err)
+}
+
+// readAll reads the records of r, with at most 50 records.
+func readAll(r *Reader) []record {
+	var got []record
+	for len(got) <= 50 {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var rec record
+		for i, f := range fields {
+			rec.fields = append(rec.fields, strings.Clone(f.String()))
+			line, col := r.FieldPos(i)
+			rec.pos = append(rec.pos, [2]int{line, col})
+		}
+		if err != nil {
+			rec.err = err.Error()
+		}
+		got = append(got, rec)
+	}
+	return got
+}
+
+// readAllCSV reads the records of r as readAll does.
+func readAllCSV(r *csv.Reader) []record {
+	var got []record
+	for len(got) <= 50 {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		rec := record{fields: fields}
+		for i := range fields {
+			line, col := r.FieldPos(i)
+			rec.pos = append(rec.pos, [2]int{line, col})
+		}
+		if err != nil {
+			rec.err = err.Error()
+		}
+		got = append(got, rec)
+	}
+	return got
+}
+
+// newReaders returns Readers of s from a Str, from an io.Reader, and from an
+// io.Reader returning one byte at a time, with the options of opts.
+func newReaders(s string, opts *Reader) []*Reader {
+	rs := []*Reader{
+		NewReader(str.NewFromString(s)),
+		NewStreamReader(strings.NewReader(s)),
+		NewStreamReader(iotest.OneByteReader(strings.NewReader(s))),
+	}
+	for _, r := range rs {
+		r.Comma, r.Comment = opts.Comma, opts.Comment
+		r.FieldsPerRecord, r.LazyQuotes, r.TrimLeadingSpace = opts.FieldsPerRecord, opts.LazyQuotes, opts.TrimLeadingSpace
+	}
+	return rs
+}
+
+func TestRead(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input string
+		opts  Reader
+		want  []record
+	}{
+		{
+			name:  "simple",
+			input: "a,b,c\nd,e,f\n",
+			want: []record{
+				{fields: []string{"a", "b", "c"}, pos: [][2]int{{1, 1}, {1, 3}, {1, 5}}},
+				{fields: []string{"d", "e", "f"}, pos: [][2]int{{2, 1}, {2, 3}, {2, 5}}},
+			},
+		},
+		{
+			name:  "CRLFAndEmptyLines",
+			input: "a,b\r\n\r\n\nc,\r\n\r",
+			want: []record{
+				{fields: []string{"a", "b"}, pos: [][2]int{{1, 1}, {1, 3}}},
+				{fields: []string{"c", ""}, pos: [][2]int{{4, 1}, {4, 3}}},
+			},
+		},
+		{
+			name:  "QuotedMultiline",
+			input: "a,\"b\nc\r\nd\",e\nf,g,h",
+			want: []record{
+				{fields: []string{"a", "b\nc\nd", "e"}, pos: [][2]int{{1, 1}, {1, 3}, {3, 4}}},
+				{fields: []string{"f", "g", "h"}, pos: [][2]int{{4, 1}, {4, 3}, {4, 5}}},
+			},
+		},
+		{
+			name:  "DoubledQuotes",
+			input: "\"a\"\"b\",\"\"\"\",\"\"\n",
+			want: []record{
+				{fields: []string{"a\"b", "\"", ""}, pos: [][2]int{{1, 1}, {1, 8}, {1, 13}}},
+			},
+		},
+		{
+			name:  "LazyQuotes",
+			input: "a\"b,\"c\"d\",\"e\n",
+			opts:  Reader{LazyQuotes: true},
+			want: []record{
+				{fields: []string{"a\"b", "c\"d", "e\n"}, pos: [][2]int{{1, 1}, {1, 5}, {1, 11}}},
+			},
+		},
+		{
+			name:  "TrimLeadingSpace",
+			input: "  a,\t b, \"c \"\n",
+			opts:  Reader{TrimLeadingSpace: true},
+			want: []record{
+				{fields: []string{"a", "b", "c "}, pos: [][2]int{{1, 3}, {1, 7}, {1, 10}}},
+			},
+		},
+		{
+			name:  "Comment",
+			input: "#a,b\nc,#d\n #e\n#\"f\ng\n",
+			opts:  Reader{Comment: '#', FieldsPerRecord: -1},
+			want: []record{
+				{fields: []string{"c", "#d"}, pos: [][2]int{{2, 1}, {2, 3}}},
+				{fields: []string{" #e"}, pos: [][2]int{{3, 1}}},
+				{fields: []string{"g"}, pos: [][2]int{{5, 1}}},
+			},
+		},
+		{
+			name:  "MultiByteComma",
+			input: "aébé\"cé\"é\n",
+			opts:  Reader{Comma: 'é'},
+			want: []record{
+				{fields: []string{"a", "b", "cé", ""}, pos: [][2]int{{1, 1}, {1, 4}, {1, 7}, {1, 14}}},
+			},
+		},
+		{
+			name:  "TabComma",
+			input: "a\tb,c\t\"d\te\"\n",
+			opts:  Reader{Comma: '\t'},
+			want: []record{
+				{fields: []string{"a", "b,c", "d\te"}, pos: [][2]int{{1, 1}, {1, 3}, {1, 7}}},
+			},
+		},
+		{
+			name:  "BareQuote",
+			input: "a,b\nc,d\"e\n",
+			want: []record{
+				{fields: []string{"a", "b"}, pos: [][2]int{{1, 1}, {1, 3}}},
+				{fields: []string{"c"}, pos: [][2]int{{2, 1}}, err: "parse error on line 2, column 4: bare \" in non-quoted-field"},
+			},
+		},
+		{
+			name:  "ExtraneousQuote",
+			input: "a,\"b\nc\"d,e\n",
+			want: []record{
+				{fields: []string{"a"}, pos: [][2]int{{1, 1}}, err: "record on line 1; parse error on line 2, column 2: extraneous or missing \" in quoted-field"},
+			},
+		},
+		{
+			name:  "MissingQuote",
+			input: "a,b\n\"c\nd",
+			want: []record{
+				{fields: []string{"a", "b"}, pos: [][2]int{{1, 1}, {1, 3}}},
+				{err: "record on line 2; parse error on line 3, column 2: extraneous or missing \" in quoted-field"},
+			},
+		},
+		{
+			name:  "FieldCount",
+			input: "a,b\nc\nd,e,f\ng,h\n",
+			want: []record{
+				{fields: []string{"a", "b"}, pos: [][2]int{{1, 1}, {1, 3}}},
+				{fields: []string{"c"}, pos: [][2]int{{2, 1}}, err: "record on line 2: wrong number of fields"},
+				{fields: []string{"d", "e", "f"}, pos: [][2]int{{3, 1}, {3, 3}, {3, 5}}, err: "record on line 3: wrong number of fields"},
+				{fields: []string{"g", "h"}, pos: [][2]int{{4, 1}, {4, 3}}},
+			},
+		},
+		{
+			name:  "FieldsPerRecord",
+			input: "a,b,c\n",
+			opts:  Reader{FieldsPerRecord: 2},
+			want: []record{
+				{fields: []string{"a", "b", "c"}, pos: [][2]int{{1, 1}, {1, 3}, {1, 5}}, err: "record on line 1: wrong number of fields"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.Comma == 0 {
+				tt.opts.Comma = ','
+			}
+			for i, r := range newReaders(tt.input, &tt.opts) {
+				if got := readAll(r); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("reader %d:\n got %v\nwant %v", i, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		wantErr *ParseError
+	}{
+		{"a\"b\n", &ParseError{StartLine: 1, Line: 1, Column: 2, Err: ErrBareQuote}},
+		{"x\n\"a\"b\n", &ParseError{StartLine: 2, Line: 2, Column: 3, Err: ErrQuote}},
+		{"\"a\n\nb", &ParseError{StartLine: 1, Line: 3, Column: 2, Err: ErrQuote}},
+		{"a,\"b\nc\"d\n", &ParseError{StartLine: 1, Line: 2, Column: 2, Err: ErrQuote}},
+		{"a,b\nc\n", &ParseError{StartLine: 2, Line: 2, Column: 1, Err: ErrFieldCount}},
+	} {
+		r := NewReader(str.NewFromString(tt.input))
+		var err error
+		for _, err = range r.Records() {
+			if err != nil {
+				break
+			}
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) || *perr != *tt.wantErr {
+			t.Errorf("Read(%q) error = %#v, want %#v", tt.input, err, tt.wantErr)
+		}
+		if !errors.Is(err, tt.wantErr.Err) {
+			t.Errorf("Read(%q) error = %v, want %v", tt.input, err, tt.wantErr.Err)
+		}
+	}
+}
+
+func TestInvalidDelim(t *testing.T) {
+	for _, opts := range []Reader{{Comma: '"'}, {Comma: '\n'}, {Comma: ',', Comment: ','}, {Comma: 0xD800}} {
+		r := NewReader(str.NewFromString("a\n"))
+		r.Comma, r.Comment = opts.Comma, opts.Comment
+		if _, err := r.Read(); err != errInvalidDelim {
+			t.Errorf("Read with Comma %q and Comment %q: error = %v, want %v", opts.Comma, opts.Comment, err, errInvalidDelim)
+		}
+	}
+}
+
+// TestStreamBufferBoundaries reads fields longer than the buffer of the
+// stream readers, which must be kept across refills.
+func TestStreamBufferBoundaries(t *testing.T) {
+	long := strings.Repeat("x", 8000)
+	var b strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&b, "%s,\"%d\n%s\"\"%s\",%d\r\n", long[:i*300], i, long[:i*400], long[:i], i)
+	}
+	input := b.String()
+	want := readAllCSV(csv.NewReader(strings.NewReader(input)))
+	for i, r := range newReaders(input, &Reader{Comma: ','}) {
+		if got := readAll(r); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("reader %d differs from encoding/csv", i)
+		}
+	}
+}
+
+// TestReadEncodingCSV compares the records, positions and errors of
+// random inputs read with random options to those of encoding/csv.
+func TestReadEncodingCSV(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	alphabet := []rune("ab,\"\r\n #\t;é")
+	for range 20000 {
+		var b strings.Builder
+		for n := rnd.Intn(20); n > 0; n-- {
+			b.WriteRune(alphabet[rnd.Intn(len(alphabet))])
+		}
+		input := b.String()
+
+		opts := Reader{
+			Comma:            []rune{',', ';', '\t', 'é'}[rnd.Intn(4)],
+			Comment:          []rune{0, '#'}[rnd.Intn(2)],
+			FieldsPerRecord:  rnd.Intn(3) - 1,
+			LazyQuotes:       rnd.Intn(2) == 0,
+			TrimLeadingSpace: rnd.Intn(2) == 0,
+		}
+		cr := csv.NewReader(strings.NewReader(input))
+		cr.Comma, cr.Comment = opts.Comma, opts.Comment
+		cr.FieldsPerRecord, cr.LazyQuotes, cr.TrimLeadingSpace = opts.FieldsPerRecord, opts.LazyQuotes, opts.TrimLeadingSpace
+		want := readAllCSV(cr)
+
+		for i, r := range newReaders(input, &opts) {
+			if got := readAll(r); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("reader %d of %q with %+v:\n got %v\nwant %v", i, input, opts, got, want)
+			}
+		}
+	}
+}
+
+func TestFieldsAreViews(t *testing.T) {
+	input := str.NewFromString("a,\"b\nc\",\"d\"\"e\",\"f\r\ng\"\n")
+	fields, err := NewReader(input).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, true, false, false} {
+		if _, ok := fields[i].OffsetIn(input); ok != want {
+			t.Errorf("field %d %q is a view of the input: %v, want %v", i, fields[i].String(), ok, want)
+		}
+	}
+
+	input = str.NewFromString(strings.Repeat("abc,def,\"ghi\"\n", 100))
+	r := NewReader(input)
+	r.Read() // allocate the record
+	allocs := testing.AllocsPerRun(100, func() {
+		r.input = input
+		for fields := range r.Records() {
+			if _, ok := fields[0].OffsetIn(input); !ok {
+				t.Fatal("field is not a view of the input")
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("reading records allocates %v times, want 0", allocs)
+	}
+}
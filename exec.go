@@ -0,0 +1,311 @@
+package str
+
+import (
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// endOfText is the rune before the start and after the end of the input.
+const endOfText rune = -1
+
+// machine is a Pike VM running a compiled regular expression, simulating
+// all its threads in lockstep over the input, in the manner of package
+// regexp. A machine keeps its queues, threads and capture buffers from
+// one match to the next, so that finding successive matches does not
+// allocate once they have grown to size.
+type machine struct {
+	re       *Regexp
+	q0, q1   queue
+	pool     []*thread
+	matched  bool
+	matchcap []int
+}
+
+// queue is a sparse set of threads ordered by priority.
+type queue struct {
+	sparse []uint32
+	dense  []entry
+}
+
+type entry struct {
+	pc uint32
+	t  *thread
+}
+
+// thread is a thread of the machine: an instruction and the captures
+// recorded on the way to it.
+type thread struct {
+	inst *syntax.Inst
+	cap  []int
+}
+
+// newMachine returns a new machine for re.
+func newMachine(re *Regexp) *machine {
+	n := len(re.prog.Inst)
+	return &machine{
+		re:       re,
+		q0:       queue{sparse: make([]uint32, n), dense: make([]entry, 0, n)},
+		q1:       queue{sparse: make([]uint32, n), dense: make([]entry, 0, n)},
+		matchcap: make([]int, 0, re.prog.NumCap),
+	}
+}
+
+// init prepares the machine to record ncap capture positions,
+// at most the number of captures of the program.
+func (m *machine) init(ncap int) {
+	if len(m.matchcap) != ncap {
+		// Pooled threads have captures of the previous size.
+		m.pool = m.pool[:0]
+		m.matchcap = m.matchcap[:ncap]
+	}
+}
+
+func (m *machine) alloc(i *syntax.Inst) *thread {
+	var t *thread
+	if n := len(m.pool); n > 0 {
+		t = m.pool[n-1]
+		m.pool = m.pool[:n-1]
+	} else {
+		t = &thread{cap: make([]int, len(m.matchcap))}
+	}
+	t.inst = i
+	return t
+}
+
+// free returns the threads of q to the pool and empties q.
+func (m *machine) free(q *queue) {
+	for _, d := range q.dense {
+		if d.t != nil {
+			m.pool = append(m.pool, d.t)
+		}
+	}
+	q.dense = q.dense[:0]
+}
+
+// step returns the rune at pos in s and its width, or endOfText at the end.
+func step(s string, pos int) (rune, int) {
+	if pos < len(s) {
+		return decodeRune(s[pos:])
+	}
+	return endOfText, 0
+}
+
+// match runs the machine on s from pos, and reports whether there is a
+// match, whose captures are then in m.matchcap.
+func (m *machine) match(s string, pos int) bool {
+	startCond := m.re.cond
+	if startCond == ^syntax.EmptyOp(0) { // impossible
+		return false
+	}
+	m.matched = false
+	for i := range m.matchcap {
+		m.matchcap[i] = -1
+	}
+
+	runq, nextq := &m.q0, &m.q1
+	r, width := step(s, pos)
+	r1, width1 := endOfText, 0
+	if r != endOfText {
+		r1, width1 = step(s, pos+width)
+	}
+	prev := endOfText
+	if pos > 0 {
+		prev, _ = utf8.DecodeLastRuneInString(s[:pos])
+	}
+	flag := syntax.EmptyOpContext(prev, r)
+	for {
+		if len(runq.dense) == 0 {
+			if startCond&syntax.EmptyBeginText != 0 && pos != 0 {
+				// Anchored match, past beginning of text.
+				break
+			}
+			if m.matched {
+				// Have match; finished exploring alternatives.
+				break
+			}
+			if m.re.prefix != "" && r != m.re.prefixRune {
+				// Skip to the next occurrence of the literal prefix.
+				advance := Index(NewFromString(s[pos:]), NewFromString(m.re.prefix))
+				if advance < 0 {
+					break
+				}
+				if advance > 0 {
+					pos += advance
+					prev, _ = utf8.DecodeLastRuneInString(s[:pos])
+				}
+				r, width = step(s, pos)
+				r1, width1 = step(s, pos+width)
+				flag = syntax.EmptyOpContext(prev, r)
+			}
+		}
+		if !m.matched && (pos == 0 || startCond&syntax.EmptyBeginText == 0) {
+			if len(m.matchcap) > 0 {
+				m.matchcap[0] = pos
+			}
+			m.add(runq, uint32(m.re.prog.Start), pos, m.matchcap, flag, nil)
+		}
+		flag = syntax.EmptyOpContext(r, r1)
+		m.step(runq, nextq, pos, pos+width, r, flag)
+		if width == 0 {
+			break
+		}
+		if len(m.matchcap) == 0 && m.matched {
+			// Found a match and not paying attention
+			// to where it is, so any match will do.
+			break
+		}
+		pos += width
+		r, width = r1, width1
+		if r != endOfText {
+			r1, width1 = step(s, pos+width)
+		}
+		runq, nextq = nextq, runq
+	}
+	m.free(nextq)
+	return m.matched
+}
+
+// step executes one step of the machine, running the threads of runq on
+// the rune c at pos and adding the threads which match it to nextq.
+func (m *machine) step(runq, nextq *queue, pos, nextPos int, c rune, nextCond syntax.EmptyOp) {
+	for j := 0; j < len(runq.dense); j++ {
+		d := &runq.dense[j]
+		t := d.t
+		if t == nil {
+			continue
+		}
+
+		i := t.inst
+		add := false
+		switch i.Op {
+		default:
+			panic("str: bad regexp instruction")
+		case syntax.InstMatch:
+			if len(t.cap) > 0 {
+				t.cap[1] = pos
+				copy(m.matchcap, t.cap)
+			}
+			// Leftmost-first semantics: cut off all lower-priority threads.
+			for _, d := range runq.dense[j+1:] {
+				if d.t != nil {
+					m.pool = append(m.pool, d.t)
+				}
+			}
+			runq.dense = runq.dense[:0]
+			m.matched = true
+		case syntax.InstRune:
+			add = i.MatchRune(c)
+		case syntax.InstRune1:
+			add = c == i.Rune[0]
+		case syntax.InstRuneAny:
+			add = true
+		case syntax.InstRuneAnyNotNL:
+			add = c != '\n'
+		}
+		if add {
+			t = m.add(nextq, i.Out, nextPos, t.cap, nextCond, t)
+		}
+		if t != nil {
+			m.pool = append(m.pool, t)
+		}
+	}
+	runq.dense = runq.dense[:0]
+}
+
+// add adds an entry to q for pc, unless q already has such an entry.
+// It also recursively adds an entry for all instructions reachable from pc
+// by following empty-width conditions satisfied by cond. pos gives the
+// current position in the input. t is a thread which can be reused,
+// which add returns if it did not use it.
+func (m *machine) add(q *queue, pc uint32, pos int, cap []int, cond syntax.EmptyOp, t *thread) *thread {
+Again:
+	if pc == 0 {
+		return t
+	}
+	if j := q.sparse[pc]; j < uint32(len(q.dense)) && q.dense[j].pc == pc {
+		return t
+	}
+
+	j := len(q.dense)
+	q.dense = q.dense[:j+1]
+	d := &q.dense[j]
+	d.t = nil
+	d.pc = pc
+	q.sparse[pc] = uint32(j)
+
+	i := &m.re.prog.Inst[pc]
+	switch i.Op {
+	default:
+		panic("str: unhandled regexp instruction")
+	case syntax.InstFail:
+		// nothing
+	case syntax.InstAlt, syntax.InstAltMatch:
+		t = m.add(q, i.Out, pos, cap, cond, t)
+		pc = i.Arg
+		goto Again
+	case syntax.InstEmptyWidth:
+		if syntax.EmptyOp(i.Arg)&^cond == 0 {
+			pc = i.Out
+			goto Again
+		}
+	case syntax.InstNop:
+		pc = i.Out
+		goto Again
+	case syntax.InstCapture:
+		if int(i.Arg) < len(cap) {
+			opos := cap[i.Arg]
+			cap[i.Arg] = pos
+			m.add(q, i.Out, pos, cap, cond, nil)
+			cap[i.Arg] = opos
+		} else {
+			pc = i.Out
+			goto Again
+		}
+	case syntax.InstMatch, syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		if t == nil {
+			t = m.alloc(i)
+		} else {
+			t.inst = i
+		}
+		if len(cap) > 0 && &t.cap[0] != &cap[0] {
+			copy(t.cap, cap)
+		}
+		d.t = t
+		t = nil
+	}
+	return t
+}
+
+// all calls deliver with the captures of all successive non-overlapping
+// matches in s, as defined by the 'All' description in the package regexp
+// documentation, until deliver returns false.
+func (m *machine) all(s string, deliver func(cap []int) bool) {
+	prevMatchEnd := -1
+	for pos := 0; pos <= len(s); {
+		if !m.match(s, pos) {
+			return
+		}
+
+		accept := true
+		start, end := m.matchcap[0], m.matchcap[1]
+		if end == pos {
+			// An empty match right after a previous match is not allowed.
+			if start == prevMatchEnd {
+				accept = false
+			}
+			// Move to the next rune.
+			if _, width := step(s, pos); width > 0 {
+				pos += width
+			} else {
+				pos = len(s) + 1
+			}
+		} else {
+			pos = end
+		}
+		prevMatchEnd = end
+
+		if accept && !deliver(m.matchcap) {
+			return
+		}
+	}
+}
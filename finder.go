@@ -0,0 +1,150 @@
+package str
+
+import (
+	"bytes"
+	"iter"
+	"unicode/utf8"
+)
+
+// Finder efficiently finds instances of a fixed pattern in strings.
+// All the search state of the pattern is computed once by [NewFinder],
+// so repeated searches for the same pattern do not rebuild it.
+//
+// A Finder is immutable and safe for concurrent use by multiple goroutines.
+// It refers to the memory of the pattern, which must not be modified
+// while the Finder is in use.
+type Finder struct {
+	pattern Str
+	// Rabin-Karp states, used for patterns up to maxShortNeedle bytes.
+	rk, rkRev rabinKarp
+	// Boyer-Moore-Horspool shift tables, used for longer patterns.
+	bmh, bmhRev horspool
+}
+
+// NewFinder returns a new Finder searching for pattern.
+func NewFinder(pattern Str) *Finder {
+	f := &Finder{pattern: pattern}
+	switch n := pattern.Len; {
+	case n < 2:
+		// Searched with byte scans only.
+	case n <= maxShortNeedle:
+		f.rk = makeRabinKarp(pattern)
+		f.rkRev = makeRabinKarpRev(pattern)
+	default:
+		f.bmh = makeHorspool(pattern)
+		f.bmhRev = makeHorspoolRev(pattern)
+	}
+	return f
+}
+
+// Pattern returns the pattern the Finder searches for.
+func (f *Finder) Pattern() Str {
+	return f.pattern
+}
+
+// Index returns the index of the first instance of the pattern in s,
+// or -1 if the pattern is not present in s.
+func (f *Finder) Index(s Str) int {
+	n := f.pattern.Len
+	switch {
+	case n == 0:
+		return 0
+	case n == 1:
+		return IndexByte(s, f.pattern.Get(0))
+	case n > s.Len:
+		return -1
+	}
+
+	i, done := scanIndex(s, f.pattern)
+	if done {
+		return i
+	}
+	if n <= maxShortNeedle {
+		return offset(i, f.rk.index(s.SliceFrom(i), f.pattern))
+	}
+	return offset(i, f.bmh.index(s.SliceFrom(i), f.pattern))
+}
+
+// LastIndex returns the index of the last instance of the pattern in s,
+// or -1 if the pattern is not present in s.
+func (f *Finder) LastIndex(s Str) int {
+	n := f.pattern.Len
+	switch {
+	case n == 0:
+		return s.Len
+	case n == 1:
+		return LastIndexByte(s, f.pattern.Get(0))
+	case n > s.Len:
+		return -1
+	}
+
+	j, done := scanLastIndex(s, f.pattern)
+	if done {
+		return j
+	}
+	if n <= maxShortNeedle {
+		return f.rkRev.lastIndex(s.SliceTo(j), f.pattern)
+	}
+	return f.bmhRev.lastIndex(s.SliceTo(j), f.pattern)
+}
+
+// Count counts the number of non-overlapping instances of the pattern in s.
+// If the pattern is empty, Count returns 1 + the number of Unicode code points in s.
+func (f *Finder) Count(s Str) int {
+	switch f.pattern.Len {
+	case 0:
+		return utf8.RuneCount(s.asBytes()) + 1
+	case 1:
+		return bytes.Count(s.asBytes(), f.pattern.asBytes())
+	}
+
+	n := 0
+	for range f.All(s) {
+		n++
+	}
+	return n
+}
+
+// All returns an iterator over the indices of non-overlapping instances of
+// the pattern in s, in increasing order. If the pattern is empty, All yields
+// the index of every UTF-8 sequence in s followed by s.Len.
+func (f *Finder) All(s Str) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if f.pattern.Len == 0 {
+			for i := range s.String() {
+				if !yield(i) {
+					return
+				}
+			}
+			yield(s.Len)
+			return
+		}
+
+		for i := 0; ; {
+			j := f.Index(s.SliceFrom(i))
+			if j < 0 || !yield(i+j) {
+				return
+			}
+			i += j + f.pattern.Len
+		}
+	}
+}
+
+// Cut slices s around the first instance of the pattern,
+// returning the text before and after it.
+// The found result reports whether the pattern appears in s.
+// If it does not, Cut returns s, "", false.
+func (f *Finder) Cut(s Str) (before, after Str, found bool) {
+	i := f.Index(s)
+	if i < 0 {
+		return s, empty, false
+	}
+
+	return s.SliceTo(i), s.SliceFrom(i + f.pattern.Len), true
+}
+
+// Split slices s into all substrings separated by the pattern,
+// with the same semantics as [Split].
+func (f *Finder) Split(s Str) iter.Seq[Str] {
+	return values(genSplit(s, f.pattern, 0, -1, f.Index))
+}
@@ -0,0 +1,204 @@
+package str
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AppendInt appends the string form of i in the given base, 2 to 36,
+// to the pending string of b and returns it finished, or returns it in
+// a newly allocated buffer if b is nil.
+func AppendInt(i int64, base int, b *Builder) Str {
+	return build(b, 20, func(dst []byte) []byte {
+		return strconv.AppendInt(dst, i, base)
+	})
+}
+
+// AppendUint appends the string form of u in the given base, 2 to 36,
+// to the pending string of b and returns it finished, or returns it in
+// a newly allocated buffer if b is nil.
+func AppendUint(u uint64, base int, b *Builder) Str {
+	return build(b, 20, func(dst []byte) []byte {
+		return strconv.AppendUint(dst, u, base)
+	})
+}
+
+// AppendFloat appends the shortest string form of f which parses back to f
+// with ParseFloat for the given bitSize, 32 or 64, to the pending string of b
+// and returns it finished, or returns it in a newly allocated buffer if b is
+// nil. Large and small exponents use the 'e' format, as [strconv.FormatFloat]
+// with format 'g' and precision -1 does.
+func AppendFloat(f float64, bitSize int, b *Builder) Str {
+	return build(b, 24, func(dst []byte) []byte {
+		return strconv.AppendFloat(dst, f, 'g', -1, bitSize)
+	})
+}
+
+// AppendQuote appends s as a double-quoted Go string literal, as produced by
+// [strconv.Quote], to the pending string of b and returns it finished, or
+// returns it in a newly allocated buffer if b is nil.
+func AppendQuote(s Str, b *Builder) Str {
+	return build(b, s.Len+2, func(dst []byte) []byte {
+		return strconv.AppendQuote(dst, s.String())
+	})
+}
+
+// NamedArg is an argument of Format referred to by name.
+type NamedArg struct {
+	Name  string
+	Value any
+}
+
+// Named returns the argument of Format named name with the given value.
+func Named(name string, value any) NamedArg {
+	return NamedArg{Name: name, Value: value}
+}
+
+// Format returns template with its placeholders replaced by args:
+//
+//	{}      the argument after the one of the previous {}, starting with the first
+//	{n}     the argument at index n, starting from 0
+//	{name}  the NamedArg named name
+//	{{, }}  a literal '{' or '}'
+//
+// The NamedArg values are not counted as arguments by {} and {n}, wherever
+// they are among args, so that Format("{} {name}", b, Named("name", x), y)
+// formats y then x.
+// Strings, Str, byte slices, booleans, integers and floating-point numbers
+// are formatted without package fmt, the numbers as by AppendInt, AppendUint
+// and AppendFloat, in base 10. Errors and [fmt.Stringer] values are formatted
+// with their method, and other values as by [fmt.Append]. Placeholders without
+// a corresponding argument are kept as they are.
+//
+// The result is appended to the pending string of b and returned finished,
+// or returned in a newly allocated buffer if b is nil.
+func Format(template Str, b *Builder, args ...any) Str {
+	return build(b, template.Len+16*len(args), func(dst []byte) []byte {
+		t := template.String()
+		next := 0
+		for len(t) > 0 {
+			i := 0
+			for i < len(t) && t[i] != '{' && t[i] != '}' {
+				i++
+			}
+			dst = append(dst, t[:i]...)
+			if i == len(t) {
+				break
+			}
+			t = t[i:]
+
+			if len(t) > 1 && t[1] == t[0] {
+				// An escaped brace.
+				dst = append(dst, t[0])
+				t = t[2:]
+				continue
+			}
+			end := strings.IndexByte(t, '}')
+			if t[0] == '}' || end < 0 {
+				dst = append(dst, t[0])
+				t = t[1:]
+				continue
+			}
+
+			arg, ok := formatArg(t[1:end], &next, args)
+			if ok {
+				dst = appendArg(dst, arg)
+			} else {
+				dst = append(dst, t[:end+1]...)
+			}
+			t = t[end+1:]
+		}
+		return dst
+	})
+}
+
+// formatArg returns the argument of Format for the placeholder with the
+// given name, next being the index of the argument of the next {}.
+func formatArg(name string, next *int, args []any) (any, bool) {
+	if name == "" {
+		i := *next
+		*next++
+		return positionalArg(i, args)
+	}
+
+	if name[0] >= '0' && name[0] <= '9' {
+		i := 0
+		for j := 0; j < len(name); j++ {
+			if name[j] < '0' || name[j] > '9' || i >= len(args) {
+				return nil, false
+			}
+			i = i*10 + int(name[j]-'0')
+		}
+		return positionalArg(i, args)
+	}
+
+	for _, arg := range args {
+		if na, ok := arg.(NamedArg); ok && na.Name == name {
+			return na.Value, true
+		}
+	}
+	return nil, false
+}
+
+// positionalArg returns the argument of Format at index i, not counting
+// the NamedArg values.
+func positionalArg(i int, args []any) (any, bool) {
+	for _, arg := range args {
+		if _, ok := arg.(NamedArg); ok {
+			continue
+		}
+		if i == 0 {
+			return arg, true
+		}
+		i--
+	}
+	return nil, false
+}
+
+// appendArg appends the string form of the argument v of Format to dst.
+func appendArg(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case NamedArg:
+		return appendArg(dst, v.Value)
+	case string:
+		return append(dst, v...)
+	case Str:
+		return append(dst, v.asBytes()...)
+	case []byte:
+		return append(dst, v...)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(dst, v, 10)
+	case uintptr:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(dst, v, 'g', -1, 64)
+	case error:
+		return append(dst, v.Error()...)
+	case fmt.Stringer:
+		return append(dst, v.String()...)
+	}
+	return fmt.Append(dst, v)
+}
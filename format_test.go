@@ -0,0 +1,20 @@
+package str
+
+import "testing"
+
+func TestFormatNamedArgs(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		args     []any
+		want     string
+	}{
+		{"{} {name}", []any{Named("name", "x"), "y"}, "y x"},
+		{"{name} {} {}", []any{1, Named("name", "x"), 2}, "x 1 2"},
+		{"{1} {0} {name}", []any{Named("name", "x"), "a", "b"}, "b a x"},
+		{"{} {}", []any{Named("name", "x"), "a"}, "a {}"},
+	} {
+		if got := Format(NewFromString(tc.template), nil, tc.args...).String(); got != tc.want {
+			t.Errorf("Format(%q, %v) = %q, want %q", tc.template, tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package str
+
+import (
+	"errors"
+	"iter"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrBadPattern indicates that a glob pattern was malformed.
+var ErrBadPattern = errors.New("str: syntax error in glob pattern")
+
+// Glob is a compiled glob pattern, matching slash-separated paths.
+// The pattern syntax is:
+//
+//	pattern:
+//		{ term }
+//	term:
+//		'*'         matches any sequence of non-/ characters
+//		'?'         matches any single non-/ character
+//		'[' [ '!' | '^' ] { character-range } ']'
+//		            character class (must be non-empty), never matching /
+//		'**'        as a whole path segment, matches any sequence of characters,
+//		            and when followed by '/', zero or more whole directories
+//		'{' pattern { ',' pattern } '}'
+//		            matches any of the alternatives, which may be nested
+//		c           matches character c (c != '*', '?', '\\', '[', '{')
+//		'\\' c      matches character c
+//
+//	character-range:
+//		c           matches character c (c != '\\', '-', ']')
+//		'\\' c      matches character c
+//		lo '-' hi   matches character c for lo <= c <= hi
+//
+// Within braces, ',' and '}' must be escaped to be matched literally.
+// A ']' at the start of a character class is matched literally.
+// Other runs of '*' are equivalent to a single one.
+//
+// Matching takes time linear in the length of the pattern times the length
+// of the string, without exponential backtracking. Its state is pooled, so
+// that matching does not allocate in the steady state. A Glob is safe for
+// concurrent use by multiple goroutines.
+type Glob struct {
+	pattern string
+	prog    []globInst
+	// ncap is the number of captures: one per wildcard, character class
+	// and brace alternation outside braces.
+	ncap   int
+	states sync.Pool
+}
+
+// globOp is the operation of a glob instruction.
+type globOp uint8
+
+const (
+	globLiteral  globOp = iota // matches lit
+	globAny                    // matches any rune but '/'
+	globAnySlash               // matches any rune
+	globClass                  // matches a rune of class but '/'
+	globSplit                  // continues at x, then at y
+	globJump                   // continues at x
+	globSave                   // records the position as capture bound x
+	globMatch                  // matches the end of the string
+)
+
+type globInst struct {
+	op    globOp
+	x, y  int
+	lit   string
+	class *charClass
+}
+
+// charClass is a character class, with its ASCII characters in a bitset
+// and its other characters as ranges.
+type charClass struct {
+	ascii asciiSet
+	// ranges holds the bounds of the ranges of non-ASCII characters, in pairs.
+	ranges  []rune
+	negated bool
+}
+
+// contains reports whether r is in the class.
+func (c *charClass) contains(r rune) bool {
+	if r < utf8.RuneSelf {
+		return c.ascii.contains(byte(r)) != c.negated
+	}
+	for i := 0; i < len(c.ranges); i += 2 {
+		if c.ranges[i] <= r && r <= c.ranges[i+1] {
+			return !c.negated
+		}
+	}
+	return c.negated
+}
+
+// add adds the characters from lo to hi to the class.
+func (c *charClass) add(lo, hi rune) {
+	for r := lo; r <= hi && r < utf8.RuneSelf; r++ {
+		c.ascii.add(byte(r))
+	}
+	if hi >= utf8.RuneSelf {
+		c.ranges = append(c.ranges, max(lo, utf8.RuneSelf), hi)
+	}
+}
+
+// CompileGlob parses a glob pattern and returns, if successful,
+// a Glob that can be used to match against paths.
+// The only possible returned error is ErrBadPattern.
+func CompileGlob(pattern string) (*Glob, error) {
+	c := globCompiler{pattern: pattern}
+	if err := c.seq(); err != nil {
+		return nil, err
+	}
+	c.emit(globInst{op: globMatch})
+	return &Glob{pattern: pattern, prog: c.prog, ncap: c.ncap}, nil
+}
+
+// MustCompileGlob is like CompileGlob but panics if the pattern cannot be parsed.
+func MustCompileGlob(pattern string) *Glob {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		panic("str: CompileGlob(" + strconv.Quote(pattern) + "): " + err.Error())
+	}
+	return g
+}
+
+// globCompiler compiles a glob pattern into a program for the backtracker.
+type globCompiler struct {
+	pattern string
+	i       int
+	prog    []globInst
+	ncap    int
+	// depth is the nesting depth of braces.
+	depth int
+	// lit holds the pending literal characters.
+	lit []byte
+}
+
+// emit appends inst to the program and returns its index.
+func (c *globCompiler) emit(inst globInst) int {
+	c.prog = append(c.prog, inst)
+	return len(c.prog) - 1
+}
+
+// flush emits the pending literal characters.
+func (c *globCompiler) flush() {
+	if len(c.lit) > 0 {
+		c.emit(globInst{op: globLiteral, lit: string(c.lit)})
+		c.lit = c.lit[:0]
+	}
+}
+
+// seq compiles the terms up to the end of the pattern or, within braces,
+// up to the next ',' or '}'.
+func (c *globCompiler) seq() error {
+	p := c.pattern
+	for c.i < len(p) {
+		switch ch := p[c.i]; {
+		case c.depth > 0 && (ch == ',' || ch == '}'):
+			c.flush()
+			return nil
+		case ch == '\\':
+			if c.i+1 == len(p) {
+				return ErrBadPattern
+			}
+			_, n := decodeRune(p[c.i+1:])
+			c.lit = append(c.lit, p[c.i+1:c.i+1+n]...)
+			c.i += 1 + n
+		case ch == '?':
+			c.i++
+			c.capture(func() error {
+				c.emit(globInst{op: globAny})
+				return nil
+			})
+		case ch == '*':
+			start := c.i
+			for c.i < len(p) && p[c.i] == '*' {
+				c.i++
+			}
+			switch {
+			case c.i-start < 2 || !c.segmentStart(start) || !c.segmentEnd(c.i):
+				c.capture(func() error {
+					c.star(globAny)
+					return nil
+				})
+			case c.i < len(p) && p[c.i] == '/':
+				c.i++
+				c.capture(func() error {
+					c.dirs()
+					return nil
+				})
+			default:
+				c.capture(func() error {
+					c.star(globAnySlash)
+					return nil
+				})
+			}
+		case ch == '[':
+			if err := c.capture(c.class); err != nil {
+				return err
+			}
+		case ch == '{':
+			if err := c.capture(c.braces); err != nil {
+				return err
+			}
+		default:
+			c.lit = append(c.lit, ch)
+			c.i++
+		}
+	}
+	if c.depth > 0 {
+		return ErrBadPattern
+	}
+	c.flush()
+	return nil
+}
+
+// capture compiles a term with f, recording its bounds as a capture
+// if it is not within braces.
+func (c *globCompiler) capture(f func() error) error {
+	c.flush()
+	if c.depth > 0 {
+		return f()
+	}
+	k := c.ncap
+	c.ncap++
+	c.emit(globInst{op: globSave, x: 2 * k})
+	if err := f(); err != nil {
+		return err
+	}
+	c.emit(globInst{op: globSave, x: 2*k + 1})
+	return nil
+}
+
+// segmentStart reports whether the pattern at i starts a path segment.
+func (c *globCompiler) segmentStart(i int) bool {
+	if i == 0 {
+		return true
+	}
+	ch := c.pattern[i-1]
+	return ch == '/' || c.depth > 0 && (ch == '{' || ch == ',')
+}
+
+// segmentEnd reports whether the pattern at i ends a path segment.
+func (c *globCompiler) segmentEnd(i int) bool {
+	if i == len(c.pattern) {
+		return true
+	}
+	ch := c.pattern[i]
+	return ch == '/' || c.depth > 0 && (ch == ',' || ch == '}')
+}
+
+// star compiles a loop over op, preferring to match as much as possible.
+func (c *globCompiler) star(op globOp) {
+	loop := c.emit(globInst{op: globSplit})
+	c.emit(globInst{op: op})
+	c.emit(globInst{op: globJump, x: loop})
+	c.prog[loop].x, c.prog[loop].y = loop+1, len(c.prog)
+}
+
+// dirs compiles "**/": zero or more directories with their trailing slash.
+func (c *globCompiler) dirs() {
+	opt := c.emit(globInst{op: globSplit})
+	c.star(globAnySlash)
+	c.emit(globInst{op: globLiteral, lit: "/"})
+	c.prog[opt].x, c.prog[opt].y = opt+1, len(c.prog)
+}
+
+// class compiles the character class at the current position.
+func (c *globCompiler) class() error {
+	p := c.pattern
+	class := &charClass{}
+	c.i++ // '['
+	if c.i < len(p) && (p[c.i] == '!' || p[c.i] == '^') {
+		class.negated = true
+		c.i++
+	}
+	for first := true; ; first = false {
+		if c.i == len(p) {
+			return ErrBadPattern
+		}
+		if p[c.i] == ']' && !first {
+			c.i++
+			break
+		}
+		lo, err := c.classChar()
+		if err != nil {
+			return err
+		}
+		hi := lo
+		if c.i+1 < len(p) && p[c.i] == '-' && p[c.i+1] != ']' {
+			c.i++
+			if hi, err = c.classChar(); err != nil {
+				return err
+			}
+			if hi < lo {
+				return ErrBadPattern
+			}
+		}
+		class.add(lo, hi)
+	}
+	c.emit(globInst{op: globClass, class: class})
+	return nil
+}
+
+// classChar returns the possibly escaped character of a character class
+// at the current position.
+func (c *globCompiler) classChar() (rune, error) {
+	p := c.pattern
+	if p[c.i] == '\\' {
+		c.i++
+		if c.i == len(p) {
+			return 0, ErrBadPattern
+		}
+	} else if p[c.i] == '-' {
+		return 0, ErrBadPattern
+	}
+	r, n := decodeRune(p[c.i:])
+	c.i += n
+	return r, nil
+}
+
+// braces compiles the brace alternation at the current position: each
+// alternative but the last is preceded by a split to the next one, and
+// followed by a jump to the end.
+func (c *globCompiler) braces() error {
+	c.i++ // '{'
+	c.depth++
+	var jumps []int
+	for {
+		split := c.emit(globInst{op: globSplit})
+		c.prog[split].x = split + 1
+		if err := c.seq(); err != nil {
+			return err
+		}
+		if c.pattern[c.i] == '}' {
+			c.i++
+			c.prog[split] = globInst{op: globJump, x: split + 1}
+			break
+		}
+		c.i++ // ','
+		jumps = append(jumps, c.emit(globInst{op: globJump}))
+		c.prog[split].y = len(c.prog)
+	}
+	for _, j := range jumps {
+		c.prog[j].x = len(c.prog)
+	}
+	c.depth--
+	return nil
+}
+
+// String returns the source text used to compile the pattern.
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// NumCaptures returns the number of spans reported by MatchCaptures: one
+// for each wildcard, character class and brace alternation of the pattern,
+// those within braces being part of the alternation.
+func (g *Glob) NumCaptures() int {
+	return g.ncap
+}
+
+// Match reports whether the pattern matches all of s.
+func (g *Glob) Match(s Str) bool {
+	st := g.get()
+	defer g.states.Put(st)
+	return st.run(g.prog, s.String(), nil)
+}
+
+// MatchCaptures reports whether the pattern matches all of s and if so,
+// returns an iterator over the slices of s matched by each wildcard,
+// character class and brace alternation of the pattern, in order.
+// Wildcards match as much as possible, the leftmost first, and
+// alternatives are tried in order.
+func (g *Glob) MatchCaptures(s Str) (iter.Seq[Str], bool) {
+	st := g.get()
+	defer g.states.Put(st)
+	caps := make([]int, 2*g.ncap)
+	if !st.run(g.prog, s.String(), caps) {
+		return nil, false
+	}
+	return func(yield func(Str) bool) {
+		for i := 0; i < len(caps); i += 2 {
+			if !yield(s.Slice(caps[i], caps[i+1])) {
+				return
+			}
+		}
+	}, true
+}
+
+func (g *Glob) get() *globState {
+	st, ok := g.states.Get().(*globState)
+	if !ok {
+		st = &globState{}
+	}
+	return st
+}
+
+// globState holds the state of the backtracker: the (instruction, position)
+// pairs already visited, which cannot lead to a match the second time, and
+// the stack of pending jobs.
+type globState struct {
+	visited []uint32
+	jobs    []globJob
+}
+
+// globJob is a pending job of the backtracker: resuming at instruction pc
+// and position pos, or if restore is set, restoring capture pc to pos.
+type globJob struct {
+	pc, pos int
+	restore bool
+}
+
+// run reports whether prog matches all of s, recording the bounds of
+// the captures in caps if it is not nil.
+func (st *globState) run(prog []globInst, s string, caps []int) bool {
+	n := (len(prog)*(len(s)+1) + 31) / 32
+	if cap(st.visited) < n {
+		st.visited = make([]uint32, n)
+	} else {
+		st.visited = st.visited[:n]
+		clear(st.visited)
+	}
+
+	st.jobs = append(st.jobs[:0], globJob{})
+Jobs:
+	for len(st.jobs) > 0 {
+		j := st.jobs[len(st.jobs)-1]
+		st.jobs = st.jobs[:len(st.jobs)-1]
+		if j.restore {
+			caps[j.pc] = j.pos
+			continue
+		}
+
+		pc, pos := j.pc, j.pos
+		for {
+			k := uint(pc*(len(s)+1) + pos)
+			if st.visited[k/32]&(1<<(k%32)) != 0 {
+				continue Jobs
+			}
+			st.visited[k/32] |= 1 << (k % 32)
+
+			inst := &prog[pc]
+			switch inst.op {
+			case globLiteral:
+				if !strings.HasPrefix(s[pos:], inst.lit) {
+					continue Jobs
+				}
+				pc, pos = pc+1, pos+len(inst.lit)
+			case globAny, globAnySlash, globClass:
+				if pos == len(s) {
+					continue Jobs
+				}
+				r, n := decodeRune(s[pos:])
+				if r == '/' && inst.op != globAnySlash || inst.op == globClass && !inst.class.contains(r) {
+					continue Jobs
+				}
+				pc, pos = pc+1, pos+n
+			case globSplit:
+				st.jobs = append(st.jobs, globJob{pc: inst.y, pos: pos})
+				pc = inst.x
+			case globJump:
+				pc = inst.x
+			case globSave:
+				if caps != nil {
+					st.jobs = append(st.jobs, globJob{pc: inst.x, pos: caps[inst.x], restore: true})
+					caps[inst.x] = pos
+				}
+				pc++
+			case globMatch:
+				if pos == len(s) {
+					return true
+				}
+				continue Jobs
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package internal
+
+import "unicode/utf8"
+
+// AppendUnquoteJSON appends to dst the value of in, the contents of a JSON
+// string without its quotes: escapes are decoded, and invalid UTF-8 and
+// unpaired surrogate escapes are replaced by U+FFFD. It reports whether in
+// is valid.
+func AppendUnquoteJSON(dst []byte, in string) ([]byte, bool) {
+	for i := 0; i < len(in); {
+		c := in[i]
+		switch {
+		case c == '"' || c < 0x20:
+			return dst, false
+		case c >= utf8.RuneSelf:
+			r, n := utf8.DecodeRuneInString(in[i:])
+			dst = utf8.AppendRune(dst, r)
+			i += n
+			continue
+		case c != '\\':
+			dst = append(dst, c)
+			i++
+			continue
+		}
+
+		if i+1 == len(in) {
+			return dst, false
+		}
+		switch c := in[i+1]; c {
+		case '"', '\\', '/':
+			dst = append(dst, c)
+		case 'b':
+			dst = append(dst, '\b')
+		case 'f':
+			dst = append(dst, '\f')
+		case 'n':
+			dst = append(dst, '\n')
+		case 'r':
+			dst = append(dst, '\r')
+		case 't':
+			dst = append(dst, '\t')
+		case 'u':
+			r, ok := unhex4(in[i+2:])
+			if !ok {
+				return dst, false
+			}
+			i += 4
+			if IsSurrogate(r) {
+				// A surrogate must be followed by the other one of its pair.
+				r2, ok := rune(0), false
+				if i+3 < len(in) && in[i+2] == '\\' && in[i+3] == 'u' {
+					r2, ok = unhex4(in[i+4:])
+				}
+				if r = utf16Decode(r, r2); ok && r != utf8.RuneError {
+					i += 6
+				}
+			}
+			dst = utf8.AppendRune(dst, r)
+		default:
+			return dst, false
+		}
+		i += 2
+	}
+	return dst, true
+}
+
+// unhex4 returns the value of the 4 hexadecimal digits at the start of s.
+func unhex4(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	var r rune
+	for i := 0; i < 4; i++ {
+		if !IsHex(s[i]) {
+			return 0, false
+		}
+		r = r<<4 | rune(Unhex(s[i]))
+	}
+	return r, true
+}
+
+// IsSurrogate reports whether r is a UTF-16 surrogate.
+func IsSurrogate(r rune) bool {
+	return 0xD800 <= r && r < 0xE000
+}
+
+// utf16Decode returns the character of the surrogate pair r1, r2,
+// or U+FFFD if they are not a valid pair.
+func utf16Decode(r1, r2 rune) rune {
+	if 0xD800 <= r1 && r1 < 0xDC00 && 0xDC00 <= r2 && r2 < 0xE000 {
+		return (r1-0xD800)<<10 | (r2 - 0xDC00) + 0x10000
+	}
+	return utf8.RuneError
+}
@@ -0,0 +1,20 @@
+package internal
+
+// UpperHex holds the hexadecimal digits written in percent-encoded bytes.
+const UpperHex = "0123456789ABCDEF"
+
+// IsHex reports whether c is a hexadecimal digit.
+func IsHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// Unhex returns the value of the hexadecimal digit c.
+func Unhex(c byte) byte {
+	return 9*(c>>6) + c&15
+}
+
+// IsUnreserved reports whether c is an unreserved character of URLs,
+// as defined by RFC 3986: a letter, a digit or one of -._~.
+func IsUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
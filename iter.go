@@ -1 +1,240 @@
 package str
+
+import (
+	"io"
+	"iter"
+)
+
+// Concat returns the concatenation of pieces in a newly allocated buffer.
+// pieces is iterated once, so it may be a single-use iterator, and each
+// piece is copied as soon as it is yielded, so its memory may be reused by
+// the iterator afterwards. If pieces yields no non-empty piece, the result
+// is empty and nothing is allocated.
+func Concat(pieces iter.Seq[Str]) Str {
+	var buf []byte
+	for p := range pieces {
+		buf = append(buf, p.asBytes()...)
+	}
+	if len(buf) == 0 {
+		return empty
+	}
+	return NewFromBytes(buf)
+}
+
+// WriteAll writes the concatenation of pieces to w.
+// It returns the number of bytes written and the first error encountered.
+func WriteAll(w io.Writer, pieces iter.Seq[Str]) (n int64, err error) {
+	for p := range pieces {
+		if p.Len == 0 {
+			continue
+		}
+		m, err := w.Write(p.asBytes())
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// values returns an iterator over the values of seq, or nil if seq is nil.
+func values[K, V any](seq iter.Seq2[K, V]) iter.Seq[V] {
+	if seq == nil {
+		return nil
+	}
+	return func(yield func(V) bool) {
+		for _, v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Collect returns the pieces yielded by seq in a newly allocated slice.
+func Collect(seq iter.Seq[Str]) []Str {
+	return CollectInto(nil, seq)
+}
+
+// CollectInto appends the pieces yielded by seq to dst and returns the
+// extended slice. Reusing dst across calls avoids allocations once it is
+// large enough.
+func CollectInto(dst []Str, seq iter.Seq[Str]) []Str {
+	for p := range seq {
+		dst = append(dst, p)
+	}
+	return dst
+}
+
+// MapEach returns an iterator over the pieces of seq transformed by f.
+// It is not named Map, which maps the runes of a Str as strings.Map does.
+func MapEach(seq iter.Seq[Str], f func(Str) Str) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		for p := range seq {
+			if !yield(f(p)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns an iterator over the pieces of seq satisfying keep.
+func Filter(seq iter.Seq[Str], keep func(Str) bool) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		for p := range seq {
+			if keep(p) && !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns an iterator over the first n pieces of seq.
+// seq is not iterated further once they are yielded.
+func Take(seq iter.Seq[Str], n int) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for p := range seq {
+			if !yield(p) {
+				return
+			}
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// Skip returns an iterator over the pieces of seq after the first n ones.
+func Skip(seq iter.Seq[Str], n int) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		i := 0
+		for p := range seq {
+			if i < n {
+				i++
+				continue
+			}
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Enumerate returns an iterator over the pieces of seq and their 0-based indexes.
+func Enumerate(seq iter.Seq[Str]) iter.Seq2[int, Str] {
+	return func(yield func(int, Str) bool) {
+		i := 0
+		for p := range seq {
+			if !yield(i, p) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Zip returns an iterator over the pairs of pieces of a and b with the same
+// index. It stops at the end of the shorter sequence.
+func Zip(a, b iter.Seq[Str]) iter.Seq2[Str, Str] {
+	return func(yield func(Str, Str) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for p := range a {
+			q, ok := next()
+			if !ok || !yield(p, q) {
+				return
+			}
+		}
+	}
+}
+
+// Chain returns an iterator over the pieces of each of seqs in turn.
+func Chain(seqs ...iter.Seq[Str]) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		for _, seq := range seqs {
+			for p := range seq {
+				if !yield(p) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// NonEmpty returns an iterator over the non-empty pieces of seq.
+func NonEmpty(seq iter.Seq[Str]) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		for p := range seq {
+			if p.Len > 0 && !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// TrimEach returns an iterator over the pieces of seq with leading and
+// trailing white space removed, as by [TrimSpace].
+func TrimEach(seq iter.Seq[Str]) iter.Seq[Str] {
+	return MapEach(seq, TrimSpace)
+}
+
+// Dedupe returns an iterator over the pieces of seq, skipping those equal
+// to the previous piece, like the uniq command.
+func Dedupe(seq iter.Seq[Str]) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		first, last := true, empty
+		for p := range seq {
+			if !first && Equal(p, last) {
+				continue
+			}
+			if !yield(p) {
+				return
+			}
+			first, last = false, p
+		}
+	}
+}
+
+// Join concatenates the pieces of seq, placing sep between them.
+// The result is finished in b, or built in a newly allocated buffer if b is nil.
+func Join(seq iter.Seq[Str], sep Str, b *Builder) Str {
+	return build(b, 0, func(dst []byte) []byte {
+		first := true
+		for p := range seq {
+			if !first {
+				dst = append(dst, sep.asBytes()...)
+			}
+			dst = append(dst, p.asBytes()...)
+			first = false
+		}
+		return dst
+	})
+}
+
+// First returns the first piece of seq, and whether there is one.
+func First(seq iter.Seq[Str]) (Str, bool) {
+	for p := range seq {
+		return p, true
+	}
+	return empty, false
+}
+
+// Last returns the last piece of seq, and whether there is one.
+func Last(seq iter.Seq[Str]) (last Str, ok bool) {
+	for p := range seq {
+		last, ok = p, true
+	}
+	return last, ok
+}
+
+// Nth returns the piece of seq with 0-based index n, and whether there is one.
+func Nth(seq iter.Seq[Str], n int) (Str, bool) {
+	if n < 0 {
+		return empty, false
+	}
+	return First(Skip(seq, n))
+}
@@ -0,0 +1,190 @@
+package json
+
+import (
+	"strings"
+
+	"github.com/rprtr258/str"
+	. "github.com/rprtr258/str/internal"
+)
+
+// Get returns the value at path in doc, its kind, and whether there is one.
+// Each element of path is either the key of a member of an object, or the
+// decimal index of an element of an array. Strings are returned quoted,
+// and objects and arrays with all their contents. Keys are compared with
+// path once decoded, and if an object has several members with the same
+// key, Get finds the first one, whereas encoding/json keeps the last one.
+//
+// Get skips over the values which are not on path without decoding them
+// and without allocating: it only checks their nesting, so that it may
+// find a value in a malformed document. Use Validate to check a document.
+func Get(doc str.Str, path ...str.Str) (str.Str, Kind, bool) {
+	s := doc.String()
+	i := skipSpace(s, 0)
+	for _, elem := range path {
+		if i = lookup(s, i, elem); i < 0 {
+			return str.Str{}, Invalid, false
+		}
+	}
+
+	end := skipValue(s, i)
+	if end < 0 {
+		return str.Str{}, Invalid, false
+	}
+	return doc.Slice(i, end), kindOf(s[i]), true
+}
+
+// lookup returns the offset of the value for elem in the object or array
+// at i in s, or -1 if there is none.
+func lookup(s string, i int, elem str.Str) int {
+	if i == len(s) {
+		return -1
+	}
+
+	switch s[i] {
+	case '{':
+		i = skipSpace(s, i+1)
+		if i < len(s) && s[i] == '}' {
+			return -1
+		}
+		for {
+			if i == len(s) || s[i] != '"' {
+				return -1
+			}
+			end := skipString(s, i)
+			if end < 0 {
+				return -1
+			}
+			key := s[i+1 : end-1]
+			if i = skipSpace(s, end); i == len(s) || s[i] != ':' {
+				return -1
+			}
+			i = skipSpace(s, i+1)
+			if keyEqual(key, elem.String()) {
+				return i
+			}
+			if i = next(s, i); i < 0 {
+				return -1
+			}
+		}
+	case '[':
+		n, ok := index(elem.String())
+		if !ok {
+			return -1
+		}
+		i = skipSpace(s, i+1)
+		if i < len(s) && s[i] == ']' {
+			return -1
+		}
+		for ; n > 0; n-- {
+			if i = next(s, i); i < 0 {
+				return -1
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// next skips the value at i in s and the comma after it, and returns the
+// offset of the next member or element, or -1 if the container ends there
+// or is malformed.
+func next(s string, i int) int {
+	if i = skipValue(s, i); i < 0 {
+		return -1
+	}
+	if i = skipSpace(s, i); i == len(s) || s[i] != ',' {
+		return -1
+	}
+	return skipSpace(s, i+1)
+}
+
+// index returns the value of the array index elem.
+func index(elem string) (int, bool) {
+	if elem == "" || len(elem) > 9 {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(elem); i++ {
+		if !isDigit(elem[i]) {
+			return 0, false
+		}
+		n = n*10 + int(elem[i]-'0')
+	}
+	return n, true
+}
+
+// skipString returns the end of the string starting at i in s,
+// or -1 if it is not terminated.
+func skipString(s string, i int) int {
+	for i++; ; i++ {
+		j := strings.IndexByte(s[i:], '"')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		// The quote is escaped if it follows an odd number of backslashes.
+		k := i
+		for s[k-1] == '\\' {
+			k--
+		}
+		if (i-k)%2 == 0 {
+			return i + 1
+		}
+	}
+}
+
+// skipValue returns the end of the value starting at i in s, or -1 if it
+// is malformed. Only the nesting of objects and arrays is checked.
+func skipValue(s string, i int) int {
+	if i == len(s) {
+		return -1
+	}
+
+	switch kindOf(s[i]) {
+	case String:
+		return skipString(s, i)
+	case Object, Array:
+		depth := 0
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '"':
+				if i = skipString(s, i); i < 0 {
+					return -1
+				}
+				i--
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	case Number:
+		j := i + 1
+		for j < len(s) && (isDigit(s[j]) || strings.IndexByte("+-.eE", s[j]) >= 0) {
+			j++
+		}
+		return j
+	case Literal:
+		for _, lit := range [...]string{"true", "false", "null"} {
+			if strings.HasPrefix(s[i:], lit) {
+				return i + len(lit)
+			}
+		}
+	}
+	return -1
+}
+
+// keyEqual reports whether the raw object key, without its quotes,
+// stands for name. Keys with escapes are decoded as str.UnquoteJSON does,
+// in a buffer on the stack unless they are long.
+func keyEqual(key, name string) bool {
+	if strings.IndexByte(key, '\\') < 0 {
+		return key == name
+	}
+	var buf [64]byte
+	dec, ok := AppendUnquoteJSON(buf[:0], key)
+	return ok && string(dec) == name
+}
@@ -0,0 +1,128 @@
+package json
+
+import (
+	stdjson "encoding/json"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rprtr258/str"
+)
+
+func TestGet(t *testing.T) {
+	for _, tc := range []struct {
+		doc  string
+		path []string
+		want string
+		kind Kind
+	}{
+		{`[1, 2]`, nil, "[1, 2]", Array},
+		{` {"a": {"b": [true, null]}} `, []string{"a"}, `{"b": [true, null]}`, Object},
+		{`{"a": {"b": [true, null]}}`, []string{"a", "b", "1"}, "null", Literal},
+		{`[[1, 2], {"a": ["x\\"]}]`, []string{"1", "a", "0"}, `"x\\"`, String},
+		{`[1, 2]`, []string{"2"}, "", Invalid},
+		{`[1, 2]`, []string{"-1"}, "", Invalid},
+		{`[1, 2]`, []string{"a"}, "", Invalid},
+		{`{"a": 1}`, []string{"0"}, "", Invalid},
+		{`"a"`, []string{"a"}, "", Invalid},
+		// Values are found in malformed documents up to their end,
+		// but not past a malformed value.
+		{`{"a": 1, "b": }`, []string{"a"}, "1", Number},
+		{`{"a": 1, "b": }`, []string{"b"}, "", Invalid},
+		{`[1, 2`, []string{"1"}, "2", Number},
+		{`[1, 2`, []string{"2"}, "", Invalid},
+		{`{"a" 1}`, []string{"a"}, "", Invalid},
+		{`{"a": "x}`, []string{"a"}, "", Invalid},
+		{`{"a":1,,"b":2}`, []string{"b"}, "", Invalid},
+		{`[nul, 1]`, []string{"1"}, "", Invalid},
+		{`{"a": tru}`, []string{"a"}, "", Invalid},
+	} {
+		var path []str.Str
+		for _, elem := range tc.path {
+			path = append(path, str.NewFromString(elem))
+		}
+		v, kind, ok := Get(str.NewFromString(tc.doc), path...)
+		if ok != (tc.kind != Invalid) || kind != tc.kind || v.String() != tc.want {
+			t.Errorf("Get(%q, %q) = %q, %v, %v, want %q, %v", tc.doc, tc.path, v.String(), kind, ok, tc.want, tc.kind)
+		}
+	}
+}
+
+// TestGetEncodingJSON compares the values at random paths of random
+// documents to those decoded by encoding/json.
+func TestGetEncodingJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 20000 {
+		s := randDoc(r, 0)
+		var doc any
+		if err := stdjson.Unmarshal([]byte(s), &doc); err != nil {
+			t.Fatalf("json.Unmarshal(%q): %v", s, err)
+		}
+
+		var path []str.Str
+		want, found := doc, true
+		for n := r.Intn(4); n > 0; n-- {
+			elem := strconv.Itoa(r.Intn(4))
+			if r.Intn(2) == 0 {
+				elem = []string{"a", "b", "é", `a\`}[r.Intn(4)]
+			}
+			path = append(path, str.NewFromString(elem))
+			switch w := want.(type) {
+			case map[string]any:
+				want, found = w[elem]
+			case []any:
+				i, err := strconv.Atoi(elem)
+				found = err == nil && i < len(w)
+				if found {
+					want = w[i]
+				}
+			default:
+				found = false
+			}
+			if !found {
+				break
+			}
+		}
+
+		v, kind, ok := Get(str.NewFromString(s), path...)
+		if ok != found {
+			t.Fatalf("Get(%q, %q) found a value: %v, want %v", s, path, ok, found)
+		}
+		if !ok {
+			continue
+		}
+		var got any
+		if err := stdjson.Unmarshal([]byte(v.String()), &got); err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("Get(%q, %q) = %q, want %v", s, path, v.String(), want)
+		}
+		if kind != kindOf(v.Get(0)) {
+			t.Fatalf("Get(%q, %q) kind = %v, value %q", s, path, kind, v.String())
+		}
+	}
+}
+
+func TestGetKeys(t *testing.T) {
+	doc := str.NewFromString(`{"a\"b": 1, "é😀": 2, "\ud83d": 3, "a": 4, "a": 5, "` +
+		`a very long key with an escape, decoded beyond the stack buffer\n": 6}`)
+	for _, tc := range []struct {
+		key, want string
+	}{
+		{`a"b`, "1"},
+		{"é😀", "2"},
+		{"\uFFFD", "3"},
+		// The first of duplicate keys is found.
+		{"a", "4"},
+		{"a very long key with an escape, decoded beyond the stack buffer\n", "6"},
+	} {
+		v, kind, ok := Get(doc, str.NewFromString(tc.key))
+		if !ok || kind != Number || v.String() != tc.want {
+			t.Errorf("Get(%q) = %q, %v, %v, want %q", tc.key, v.String(), kind, ok, tc.want)
+		}
+	}
+
+	key := str.NewFromString("é😀")
+	if n := testing.AllocsPerRun(100, func() { Get(doc, key) }); n != 0 {
+		t.Errorf("Get with an escaped key: %v allocations, want 0", n)
+	}
+}
@@ -0,0 +1,403 @@
+// Package json tokenizes and queries JSON documents, as defined by RFC 8259,
+// held in Str values. Tokens and values are returned as views into the
+// document: strings are kept quoted, to be decoded when needed with
+// [str.UnquoteJSON].
+package json
+
+import (
+	"io"
+	"iter"
+	"strconv"
+
+	"github.com/rprtr258/str"
+	. "github.com/rprtr258/str/internal"
+)
+
+// Kind is the kind of a token or of a value.
+type Kind uint8
+
+const (
+	Invalid Kind = iota
+	// Delim is the kind of the delimiters of objects and arrays:
+	// '{', '}', '[' and ']'.
+	Delim
+	String
+	Number
+	// Literal is the kind of true, false and null.
+	Literal
+	Object
+	Array
+)
+
+var kindNames = [...]string{
+	Invalid: "invalid",
+	Delim:   "delim",
+	String:  "string",
+	Number:  "number",
+	Literal: "literal",
+	Object:  "object",
+	Array:   "array",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// kindOf returns the kind of the value starting with c.
+func kindOf(c byte) Kind {
+	switch c {
+	case '{':
+		return Object
+	case '[':
+		return Array
+	case '"':
+		return String
+	case 't', 'f', 'n':
+		return Literal
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return Number
+	}
+	return Invalid
+}
+
+// Token is a token of a JSON document. Colons and commas are not tokens.
+type Token struct {
+	Kind Kind
+	// Value is the text of the token in the document, quotes included
+	// for strings.
+	Value str.Str
+	// Offset is the byte offset of the token in the document.
+	Offset int
+}
+
+// SyntaxError is a description of a JSON syntax error, at byte Offset of
+// the document.
+type SyntaxError struct {
+	msg    string
+	Offset int
+}
+
+func (e *SyntaxError) Error() string {
+	return e.msg
+}
+
+// quoteChar formats c as a quoted character literal.
+func quoteChar(c byte) string {
+	if c == '\'' {
+		return `'\''`
+	}
+	if c == '"' {
+		return `'"'`
+	}
+	s := strconv.Quote(string(rune(c)))
+	return "'" + s[1:len(s)-1] + "'"
+}
+
+// state is what the next token of a Tokenizer can be.
+type state uint8
+
+const (
+	stateValue      state = iota // a value
+	stateValueOrEnd              // a value or the end of an array
+	stateKeyOrEnd                // an object key or the end of an object
+	stateKey                     // an object key, after a comma
+	stateColon                   // the colon after an object key
+	stateCommaOrEnd              // a comma or the end of the container
+	stateEnd                     // the end of the document
+)
+
+// Tokenizer reads the tokens of a JSON document, checking its syntax.
+type Tokenizer struct {
+	doc str.Str
+	s   string
+	pos int
+	// stack holds the delimiters of the open containers.
+	stack []byte
+	state state
+	err   error
+}
+
+// NewTokenizer returns a new Tokenizer reading doc.
+func NewTokenizer(doc str.Str) *Tokenizer {
+	return &Tokenizer{doc: doc, s: doc.String()}
+}
+
+// Depth returns the number of objects and arrays open after the last token.
+func (t *Tokenizer) Depth() int {
+	return len(t.stack)
+}
+
+// Next returns the next token of the document. At the end of the document,
+// it returns io.EOF, and if the document is malformed, a *SyntaxError,
+// which it keeps returning afterwards.
+func (t *Tokenizer) Next() (Token, error) {
+	if t.err != nil {
+		return Token{}, t.err
+	}
+	tok, err := t.next()
+	if err != nil {
+		t.err = err
+	}
+	return tok, err
+}
+
+// All returns an iterator over the tokens of the document, ending with
+// the syntax error if there is one.
+func (t *Tokenizer) All() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for {
+			tok, err := t.Next()
+			if err == io.EOF || !yield(tok, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *Tokenizer) error(offset int, msg string) error {
+	return &SyntaxError{msg: msg, Offset: offset}
+}
+
+// errorAt returns the error for an unexpected character at t.pos,
+// or the end of the document, with the given context.
+func (t *Tokenizer) errorAt(context string) error {
+	if t.pos == len(t.s) {
+		return t.error(t.pos, "unexpected end of JSON input")
+	}
+	return t.error(t.pos, "invalid character "+quoteChar(t.s[t.pos])+" "+context)
+}
+
+// afterValue returns the state after a complete value.
+func (t *Tokenizer) afterValue() state {
+	if len(t.stack) == 0 {
+		return stateEnd
+	}
+	return stateCommaOrEnd
+}
+
+// token returns the token from start to t.pos.
+func (t *Tokenizer) token(kind Kind, start int) Token {
+	return Token{Kind: kind, Value: t.doc.Slice(start, t.pos), Offset: start}
+}
+
+func (t *Tokenizer) next() (Token, error) {
+	for {
+		t.pos = skipSpace(t.s, t.pos)
+		if t.pos == len(t.s) {
+			if t.state == stateEnd {
+				return Token{}, io.EOF
+			}
+			return Token{}, t.errorAt("")
+		}
+
+		c, start := t.s[t.pos], t.pos
+		switch t.state {
+		case stateEnd:
+			return Token{}, t.errorAt("after top-level value")
+		case stateColon:
+			if c != ':' {
+				return Token{}, t.errorAt("after object key")
+			}
+			t.pos++
+			t.state = stateValue
+			continue
+		case stateCommaOrEnd:
+			top := t.stack[len(t.stack)-1]
+			switch {
+			case c == ',':
+				t.pos++
+				t.state = stateValue
+				if top == '{' {
+					t.state = stateKey
+				}
+				continue
+			case c == top+2: // '}' or ']'
+				t.pos++
+				t.stack = t.stack[:len(t.stack)-1]
+				t.state = t.afterValue()
+				return t.token(Delim, start), nil
+			case top == '{':
+				return Token{}, t.errorAt("after object key:value pair")
+			}
+			return Token{}, t.errorAt("after array element")
+		case stateKeyOrEnd, stateKey:
+			if c == '}' && t.state == stateKeyOrEnd {
+				t.pos++
+				t.stack = t.stack[:len(t.stack)-1]
+				t.state = t.afterValue()
+				return t.token(Delim, start), nil
+			}
+			if c != '"' {
+				return Token{}, t.errorAt("looking for beginning of object key string")
+			}
+			if err := t.scanString(); err != nil {
+				return Token{}, err
+			}
+			t.state = stateColon
+			return t.token(String, start), nil
+		case stateValueOrEnd:
+			if c == ']' {
+				t.pos++
+				t.stack = t.stack[:len(t.stack)-1]
+				t.state = t.afterValue()
+				return t.token(Delim, start), nil
+			}
+		}
+
+		// A value.
+		kind := kindOf(c)
+		var err error
+		switch kind {
+		case Object, Array:
+			t.pos++
+			t.stack = append(t.stack, c)
+			t.state = stateKeyOrEnd
+			if kind == Array {
+				t.state = stateValueOrEnd
+			}
+			return t.token(Delim, start), nil
+		case String:
+			err = t.scanString()
+		case Number:
+			err = t.scanNumber()
+		case Literal:
+			err = t.scanLiteral()
+		default:
+			return Token{}, t.errorAt("looking for beginning of value")
+		}
+		if err != nil {
+			return Token{}, err
+		}
+		t.state = t.afterValue()
+		return t.token(kind, start), nil
+	}
+}
+
+// skipSpace returns the offset of the first non-white space byte of s
+// from i, or len(s).
+func skipSpace(s string, i int) int {
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanString advances over the string at t.pos.
+func (t *Tokenizer) scanString() error {
+	t.pos++
+	for t.pos < len(t.s) {
+		c := t.s[t.pos]
+		switch {
+		case c == '"':
+			t.pos++
+			return nil
+		case c < 0x20:
+			return t.errorAt("in string literal")
+		case c == '\\':
+			t.pos++
+			if t.pos == len(t.s) {
+				return t.errorAt("")
+			}
+			switch t.s[t.pos] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				t.pos++
+			case 'u':
+				t.pos++
+				for range 4 {
+					if t.pos == len(t.s) || !IsHex(t.s[t.pos]) {
+						return t.errorAt("in \\u hexadecimal character escape")
+					}
+					t.pos++
+				}
+			default:
+				return t.errorAt("in string escape code")
+			}
+		default:
+			t.pos++
+		}
+	}
+	return t.errorAt("")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digits advances over the digits at t.pos, of which there must be one.
+func (t *Tokenizer) digits(context string) error {
+	if t.pos == len(t.s) || !isDigit(t.s[t.pos]) {
+		return t.errorAt(context)
+	}
+	for t.pos < len(t.s) && isDigit(t.s[t.pos]) {
+		t.pos++
+	}
+	return nil
+}
+
+// scanNumber advances over the number at t.pos.
+func (t *Tokenizer) scanNumber() error {
+	if t.s[t.pos] == '-' {
+		t.pos++
+	}
+	if t.pos < len(t.s) && t.s[t.pos] == '0' {
+		t.pos++
+	} else if err := t.digits("in numeric literal"); err != nil {
+		return err
+	}
+	if t.pos < len(t.s) && t.s[t.pos] == '.' {
+		t.pos++
+		if err := t.digits("after decimal point in numeric literal"); err != nil {
+			return err
+		}
+	}
+	if t.pos < len(t.s) && (t.s[t.pos] == 'e' || t.s[t.pos] == 'E') {
+		t.pos++
+		if t.pos < len(t.s) && (t.s[t.pos] == '+' || t.s[t.pos] == '-') {
+			t.pos++
+		}
+		if err := t.digits("in exponent of numeric literal"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanLiteral advances over the literal true, false or null at t.pos.
+func (t *Tokenizer) scanLiteral() error {
+	lit := "null"
+	switch t.s[t.pos] {
+	case 't':
+		lit = "true"
+	case 'f':
+		lit = "false"
+	}
+	for i := 0; i < len(lit); i++ {
+		if t.pos == len(t.s) || t.s[t.pos] != lit[i] {
+			return t.errorAt("in literal " + lit + " (expecting " + quoteChar(lit[i]) + ")")
+		}
+		t.pos++
+	}
+	return nil
+}
+
+// Validate reports whether doc is a single valid JSON value, possibly
+// surrounded by white space, and returns a *SyntaxError otherwise.
+func Validate(doc str.Str) error {
+	t := Tokenizer{doc: doc, s: doc.String()}
+	for {
+		if _, err := t.next(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
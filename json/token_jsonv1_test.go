@@ -0,0 +1,48 @@
+//go:build !goexperiment.jsonv2
+
+package json
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/rprtr258/str"
+)
+
+// TestValidateEncodingJSON compares the syntax errors of random malformed
+// documents to those of encoding/json, whose messages are those of its v1
+// implementation only. Run it with GOEXPERIMENT=nojsonv2 when jsonv2 is on.
+func TestValidateEncodingJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 50000 {
+		s := mutate(r, randDoc(r, 0))
+		err := Validate(str.NewFromString(s))
+		var v any
+		wantErr := stdjson.Unmarshal([]byte(s), &v)
+		if (err == nil) != (wantErr == nil) {
+			t.Fatalf("Validate(%q) = %v, json.Unmarshal: %v", s, err, wantErr)
+		}
+		if err == nil {
+			continue
+		}
+
+		var se *SyntaxError
+		var want *stdjson.SyntaxError
+		if !errors.As(err, &se) || !errors.As(wantErr, &want) {
+			t.Fatalf("Validate(%q) = %v, json.Unmarshal: %v", s, err, wantErr)
+		}
+		// encoding/json reports the end of the document as a space after
+		// numbers and literals, and offsets one past the invalid character.
+		if se.Offset == len(s) {
+			if want.Offset != int64(len(s)) {
+				t.Fatalf("Validate(%q) = %v at the end, json.Unmarshal: %v at %d", s, err, wantErr, want.Offset)
+			}
+			continue
+		}
+		if se.Error() != want.Error() || int64(se.Offset) != want.Offset-1 {
+			t.Fatalf("Validate(%q) = %v at %d, json.Unmarshal: %v at %d", s, err, se.Offset, wantErr, want.Offset)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/rprtr258/str"
+)
+
+// randDoc returns a random valid document, whose objects have no
+// duplicate keys once decoded.
+func randDoc(r *rand.Rand, depth int) string {
+	switch k := r.Intn(8); {
+	case depth > 3 || k < 2:
+		values := []string{"1", "-0", "0.5e+3", "12E-2", "true", "false", "null", `"a"`, `"é\n"`, `"\\"`, `"x\"y"`, `"😀"`}
+		return values[r.Intn(len(values))]
+	case k < 5:
+		s := "["
+		for i, n := 0, r.Intn(4); i < n; i++ {
+			if i > 0 {
+				s += ", "
+			}
+			s += randDoc(r, depth+1)
+		}
+		return s + "]"
+	default:
+		keys := []string{`"a"`, `"b"`, `"\u0061"`, `"a\\"`, `"é"`, `"\u00e9"`}
+		used := map[string]bool{}
+		s := "{"
+		for n := r.Intn(4); n > 0; n-- {
+			key := keys[r.Intn(len(keys))]
+			var name string
+			stdjson.Unmarshal([]byte(key), &name)
+			if used[name] {
+				continue
+			}
+			used[name] = true
+			if len(s) > 1 {
+				s += ","
+			}
+			s += key + " : " + randDoc(r, depth+1)
+		}
+		return s + "}"
+	}
+}
+
+// mutate returns s with up to two bytes removed, replaced or inserted.
+func mutate(r *rand.Rand, s string) string {
+	const chars = "{}[]\",:\\ 0e.-tx"
+	b := []byte(s)
+	for n := r.Intn(3); n > 0 && len(b) > 0; n-- {
+		i := r.Intn(len(b))
+		switch r.Intn(3) {
+		case 0:
+			b = append(b[:i], b[i+1:]...)
+		case 1:
+			b[i] = chars[r.Intn(len(chars))]
+		default:
+			b = append(b[:i], append([]byte{chars[r.Intn(len(chars))]}, b[i:]...)...)
+		}
+	}
+	return string(b)
+}
+
+func TestValidate(t *testing.T) {
+	// The messages are those of encoding/json, whose offsets are one past
+	// ours except at the end of the document. At the end of numbers and
+	// literals, encoding/json reports an unexpected space instead.
+	for _, tc := range []struct {
+		doc    string
+		msg    string
+		offset int
+	}{
+		{` {"a": [1, -2.5e3, "x\"y"], "b": {"c": true, "d": null}} `, "", 0},
+		{"", "unexpected end of JSON input", 0},
+		{"  ", "unexpected end of JSON input", 2},
+		{"[", "unexpected end of JSON input", 1},
+		{`"abc`, "unexpected end of JSON input", 4},
+		{"1.", "unexpected end of JSON input", 2},
+		{"nul", "unexpected end of JSON input", 3},
+		{"]", "invalid character ']' looking for beginning of value", 0},
+		{"[1,]", "invalid character ']' looking for beginning of value", 3},
+		{`{"a":}`, "invalid character '}' looking for beginning of value", 5},
+		{`{"a":1,}`, "invalid character '}' looking for beginning of object key string", 7},
+		{"{1:2}", "invalid character '1' looking for beginning of object key string", 1},
+		{`{"a" 1}`, "invalid character '1' after object key", 5},
+		{`{"a":1 "b":2}`, `invalid character '"' after object key:value pair`, 7},
+		{"[1 2]", "invalid character '2' after array element", 3},
+		{"01", "invalid character '1' after top-level value", 1},
+		{"[1]]", "invalid character ']' after top-level value", 3},
+		{"truex", "invalid character 'x' after top-level value", 4},
+		{"\"a\x01\"", `invalid character '\x01' in string literal`, 2},
+		{`"\q"`, "invalid character 'q' in string escape code", 2},
+		{`"\u12g4"`, `invalid character 'g' in \u hexadecimal character escape`, 5},
+		{"-a", "invalid character 'a' in numeric literal", 1},
+		{`[1, {"b": nope}]`, "invalid character 'o' in literal null (expecting 'u')", 11},
+	} {
+		err := Validate(str.NewFromString(tc.doc))
+		if tc.msg == "" {
+			if err != nil {
+				t.Errorf("Validate(%q) = %v, want nil", tc.doc, err)
+			}
+			continue
+		}
+		var se *SyntaxError
+		if !errors.As(err, &se) || se.Error() != tc.msg || se.Offset != tc.offset {
+			t.Errorf("Validate(%q) = %v, want %s at offset %d", tc.doc, err, tc.msg, tc.offset)
+		}
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	doc := str.NewFromString(` {"a": [1, -2.5e3, "x\"y"], "b": {}} `)
+	type token struct {
+		kind   Kind
+		value  string
+		offset int
+		depth  int
+	}
+	want := []token{
+		{Delim, "{", 1, 1},
+		{String, `"a"`, 2, 1},
+		{Delim, "[", 7, 2},
+		{Number, "1", 8, 2},
+		{Number, "-2.5e3", 11, 2},
+		{String, `"x\"y"`, 19, 2},
+		{Delim, "]", 25, 1},
+		{String, `"b"`, 28, 1},
+		{Delim, "{", 33, 2},
+		{Delim, "}", 34, 1},
+		{Delim, "}", 35, 0},
+	}
+	tz := NewTokenizer(doc)
+	var got []token
+	for tok, err := range tz.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if off, ok := tok.Value.OffsetIn(doc); !ok || off != tok.Offset {
+			t.Errorf("token %q at offset %d is not a view of the document at its offset", tok.Value.String(), tok.Offset)
+		}
+		got = append(got, token{tok.Kind, tok.Value.String(), tok.Offset, tz.Depth()})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if _, err := tz.Next(); err != io.EOF {
+		t.Errorf("Next at the end = %v, want io.EOF", err)
+	}
+
+	tz = NewTokenizer(str.NewFromString("[1, 2 3]"))
+	n := 0
+	for _, err := range tz.All() {
+		if n++; err != nil {
+			break
+		}
+	}
+	_, err := tz.Next()
+	if se := (*SyntaxError)(nil); n != 4 || !errors.As(err, &se) || se.Offset != 6 {
+		t.Errorf("All yielded %d tokens, then Next returned %v, want 4 and an error at offset 6", n, err)
+	}
+}
+
+// TestTokenizerEncodingJSON compares the tokens of random documents, and
+// whether random malformed documents are valid, to encoding/json.
+func TestTokenizerEncodingJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 20000 {
+		s := randDoc(r, 0)
+		if r.Intn(2) == 0 {
+			s = mutate(r, s)
+		}
+		doc := str.NewFromString(s)
+		err := Validate(doc)
+		if valid := stdjson.Valid([]byte(s)); (err == nil) != valid {
+			t.Fatalf("Validate(%q) = %v, json.Valid = %v", s, err, valid)
+		}
+		if err != nil {
+			continue
+		}
+
+		dec := stdjson.NewDecoder(bytes.NewReader([]byte(s)))
+		dec.UseNumber()
+		tz := NewTokenizer(doc)
+		for {
+			tok, err := tz.Next()
+			wantTok, wantErr := dec.Token()
+			if err != nil || wantErr != nil {
+				if err != io.EOF || wantErr != io.EOF {
+					t.Fatalf("tokens of %q: error %v, json.Decoder: %v", s, err, wantErr)
+				}
+				break
+			}
+			if s[tok.Offset:tok.Offset+tok.Value.Len] != tok.Value.String() {
+				t.Fatalf("token %q of %q is not at offset %d", tok.Value.String(), s, tok.Offset)
+			}
+			got := tok.Value.String()
+			if tok.Kind == String {
+				u, err := str.UnquoteJSON(tok.Value, nil)
+				if err != nil {
+					t.Fatalf("UnquoteJSON(%q): %v", tok.Value.String(), err)
+				}
+				got = u.String()
+			}
+			var want string
+			switch w := wantTok.(type) {
+			case stdjson.Delim:
+				want = w.String()
+			case string:
+				want = w
+			case stdjson.Number:
+				want = string(w)
+			case bool:
+				want = strconv.FormatBool(w)
+			case nil:
+				want = "null"
+			}
+			if got != want {
+				t.Fatalf("token of %q = %q, json.Decoder: %q", s, got, want)
+			}
+		}
+	}
+}
+
+func TestValidateAllocs(t *testing.T) {
+	doc := str.NewFromString(`{"x": [1, {"y": "z\"", "aé": [true, null, -1.5e3]}], "b": {"c": 2}}`)
+	if n := testing.AllocsPerRun(100, func() { Validate(doc) }); n > 1 {
+		t.Errorf("Validate: %v allocations, want at most 1", n)
+	}
+}
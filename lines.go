@@ -0,0 +1,209 @@
+package str
+
+import (
+	"iter"
+	"slices"
+	"strings"
+
+	. "github.com/rprtr258/str/internal"
+)
+
+// lineEnd returns the length of the first line of s without its line
+// terminator, and the length of the terminator: "\n", "\r\n" or "\r",
+// and if unicode is set also "\v", "\f", U+0085 NEXT LINE, U+2028 LINE
+// SEPARATOR or U+2029 PARAGRAPH SEPARATOR.
+func lineEnd(s string, unicode bool) (end, n int) {
+	if !unicode {
+		end = strings.IndexAny(s, "\r\n")
+		switch {
+		case end < 0:
+			return len(s), 0
+		case s[end] == '\r' && end+1 < len(s) && s[end+1] == '\n':
+			return end, 2
+		}
+		return end, 1
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n', '\v', '\f':
+			return i, 1
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				return i, 2
+			}
+			return i, 1
+		case 0xC2:
+			if strings.HasPrefix(s[i:], "\u0085") {
+				return i, 2
+			}
+		case 0xE2:
+			if strings.HasPrefix(s[i:], "\u2028") || strings.HasPrefix(s[i:], "\u2029") {
+				return i, 3
+			}
+		}
+	}
+	return len(s), 0
+}
+
+// lines returns an iterator over the lines of s, with their terminators if ends is set.
+func lines(s Str, ends, unicode bool) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		str := s.String()
+		for i := 0; i < len(str); {
+			end, n := lineEnd(str[i:], unicode)
+			line := s.Slice(i, i+end)
+			if ends {
+				line = s.Slice(i, i+end+n)
+			}
+			if !yield(line) {
+				return
+			}
+			i += end + n
+		}
+	}
+}
+
+// Lines returns an iterator over the lines of s, without their line
+// terminators. Lines end with "\n", "\r\n" or a lone "\r". The terminator
+// of the last line is optional: an empty final line is not yielded.
+func Lines(s Str) iter.Seq[Str] {
+	return lines(s, false, false)
+}
+
+// LinesWithEnds returns an iterator over the lines of s, like Lines,
+// but with their line terminators. The yielded lines add up to s.
+func LinesWithEnds(s Str) iter.Seq[Str] {
+	return lines(s, true, false)
+}
+
+// UnicodeLines returns an iterator over the lines of s, like Lines, also
+// ending lines with the other mandatory breaks of Unicode: vertical tab,
+// form feed, U+0085 NEXT LINE, U+2028 LINE SEPARATOR and U+2029 PARAGRAPH
+// SEPARATOR.
+func UnicodeLines(s Str) iter.Seq[Str] {
+	return lines(s, false, true)
+}
+
+// UnicodeLinesWithEnds returns an iterator over the lines of s, like
+// UnicodeLines, but with their line terminators.
+func UnicodeLinesWithEnds(s Str) iter.Seq[Str] {
+	return lines(s, true, true)
+}
+
+// NumberedLines returns an iterator over the lines of s, as returned by
+// Lines, and their 1-based line numbers.
+func NumberedLines(s Str) iter.Seq2[int, Str] {
+	return func(yield func(int, Str) bool) {
+		n := 1
+		for line := range Lines(s) {
+			if !yield(n, line) {
+				return
+			}
+			n++
+		}
+	}
+}
+
+// LineCol returns the 1-based line and column of the byte at offset off in s,
+// lines ending as for Lines, and columns counted in bytes. off may be the
+// length of s, which is on the empty line after a final line terminator.
+// To convert many offsets of the same string, use a LineIndex.
+func LineCol(s Str, off int) (line, col int) {
+	Assert(0 <= off && off <= s.Len, "offset out of range")
+
+	str := s.String()
+	start := 0
+	for line = 1; ; line++ {
+		end, n := lineEnd(str[start:], false)
+		if n == 0 || start+end+n > off {
+			return line, off - start + 1
+		}
+		start += end + n
+	}
+}
+
+// Offset returns the byte offset in s of the 1-based line and column,
+// as returned by LineCol. To convert many positions in the same string,
+// use a LineIndex.
+func Offset(s Str, line, col int) int {
+	Assert(line >= 1, "line out of range")
+
+	str := s.String()
+	start := 0
+	for range line - 1 {
+		end, n := lineEnd(str[start:], false)
+		Assert(n > 0, "line out of range")
+		start += end + n
+	}
+	end, n := lineEnd(str[start:], false)
+	if n == 0 {
+		end++ // the end of s
+	}
+	Assert(1 <= col && col <= end+n, "column out of range")
+	return start + col - 1
+}
+
+// LineIndex converts between byte offsets and line and column positions
+// in a string, as LineCol and Offset do, in logarithmic and constant time
+// respectively, using the offsets of line starts computed once.
+// A LineIndex is safe for concurrent use by multiple goroutines.
+type LineIndex struct {
+	s Str
+	// starts holds the offsets of the line starts, including the one of
+	// the empty line after a final line terminator.
+	starts []int
+}
+
+// NewLineIndex returns a new LineIndex of s.
+func NewLineIndex(s Str) *LineIndex {
+	str := s.String()
+	starts := []int{0}
+	for i := 0; ; {
+		end, n := lineEnd(str[i:], false)
+		if n == 0 {
+			break
+		}
+		i += end + n
+		starts = append(starts, i)
+	}
+	return &LineIndex{s: s, starts: starts}
+}
+
+// LineCount returns the number of lines, counting the empty line
+// after a final line terminator.
+func (x *LineIndex) LineCount() int {
+	return len(x.starts)
+}
+
+// Line returns the 1-based line n, without its line terminator.
+func (x *LineIndex) Line(n int) Str {
+	Assert(1 <= n && n <= len(x.starts), "line out of range")
+
+	start := x.starts[n-1]
+	end, _ := lineEnd(x.s.SliceFrom(start).String(), false)
+	return x.s.Slice(start, start+end)
+}
+
+// LineCol returns the 1-based line and column of the byte at offset off.
+func (x *LineIndex) LineCol(off int) (line, col int) {
+	Assert(0 <= off && off <= x.s.Len, "offset out of range")
+
+	line, found := slices.BinarySearch(x.starts, off)
+	if found {
+		line++
+	}
+	return line, off - x.starts[line-1] + 1
+}
+
+// Offset returns the byte offset of the 1-based line and column.
+func (x *LineIndex) Offset(line, col int) int {
+	Assert(1 <= line && line <= len(x.starts), "line out of range")
+
+	start, end := x.starts[line-1], x.s.Len+1 // the end of s
+	if line < len(x.starts) {
+		end = x.starts[line]
+	}
+	Assert(1 <= col && col <= end-start, "column out of range")
+	return start + col - 1
+}
@@ -0,0 +1,359 @@
+//go:build ignore
+
+// This program generates tables.go, the Unicode property tables of the
+// package, from the Unicode Character Database. Run it with go generate.
+//
+// The database is fetched from unicode.org, or read from a local copy
+// of the ucd directory given with -ucd.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// unicodeVersion is the version of the Unicode Character Database read,
+// see its comment in tables.go.
+const unicodeVersion = "15.0.0"
+
+var (
+	ucd    = flag.String("ucd", "https://www.unicode.org/Public/"+unicodeVersion+"/ucd/", "URL or directory of the Unicode Character Database")
+	output = flag.String("output", "tables.go", "output file")
+)
+
+func open(name string) io.ReadCloser {
+	if !strings.HasPrefix(*ucd, "http://") && !strings.HasPrefix(*ucd, "https://") {
+		f, err := os.Open(filepath.Join(*ucd, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return f
+	}
+
+	resp, err := http.Get(*ucd + name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("%s: %s", name, resp.Status)
+	}
+	return resp.Body
+}
+
+// parse calls f with the code point range and the fields
+// of every data line of the UCD file name.
+func parse(name string, f func(lo, hi rune, fields []string)) {
+	r := open(name)
+	defer r.Close()
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		first, last, ok := strings.Cut(fields[0], "..")
+		if !ok {
+			last = first
+		}
+		f(parseRune(first), parseRune(last), fields[1:])
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseRune(s string) rune {
+	r, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(r)
+}
+
+// propTable assigns to every code point the index of a property value name,
+// with 0 for the default value, plus optional flags.
+type propTable struct {
+	names []string
+	flags []string
+	vals  []uint16
+}
+
+const flagShift = 8
+
+func newPropTable(names ...string) *propTable {
+	return &propTable{
+		names: append([]string{""}, names...),
+		vals:  make([]uint16, unicode.MaxRune+1),
+	}
+}
+
+// load sets the values of the property listed in UCD file name. Value names
+// are mapped to constant names by removing underscores and adding prefix.
+func (t *propTable) load(name, prefix string) {
+	index := map[string]uint16{}
+	for i, n := range t.names {
+		index[n] = uint16(i)
+	}
+	parse(name, func(lo, hi rune, fields []string) {
+		v, ok := index[prefix+strings.ReplaceAll(fields[0], "_", "")]
+		if !ok {
+			log.Fatalf("%s: unknown property value %q", name, fields[0])
+		}
+		for r := lo; r <= hi; r++ {
+			t.vals[r] = t.vals[r]&^(1<<flagShift-1) | v
+		}
+	})
+}
+
+// addFlag adds the flag constant flag to the table and returns its bit.
+func (t *propTable) addFlag(flag string) uint16 {
+	t.flags = append(t.flags, flag)
+	return 1 << (flagShift + len(t.flags) - 1)
+}
+
+// loadFlag sets the flag constant for the code points listed in UCD file
+// name with the property value prop, given as "Property" for binary
+// properties or as "Property; Value", and returns its bit.
+func (t *propTable) loadFlag(name, prop, flag string) uint16 {
+	bit := t.addFlag(flag)
+	parse(name, func(lo, hi rune, fields []string) {
+		if strings.Join(fields, "; ") != prop {
+			return
+		}
+		for r := lo; r <= hi; r++ {
+			t.vals[r] |= bit
+		}
+	})
+	return bit
+}
+
+func (t *propTable) expr(v uint16) string {
+	var terms []string
+	switch i := v & (1<<flagShift - 1); {
+	case t.names == nil:
+		// Numeric property.
+		terms = append(terms, strconv.Itoa(int(i)))
+	case i != 0:
+		terms = append(terms, t.names[i])
+	}
+	for i, f := range t.flags {
+		if v&(1<<(flagShift+i)) != 0 {
+			terms = append(terms, f)
+		}
+	}
+	return strings.Join(terms, " | ")
+}
+
+// write writes the table as a slice of propRange named name,
+// listing the ranges of code points with a value other than the default.
+func (t *propTable) write(w io.Writer, name, doc string) {
+	fmt.Fprintf(w, "// %s\nvar %s = []propRange{\n", doc, name)
+	for lo := 0; lo < len(t.vals); {
+		hi := lo
+		for hi+1 < len(t.vals) && t.vals[hi+1] == t.vals[lo] {
+			hi++
+		}
+		if t.vals[lo] != 0 {
+			fmt.Fprintf(w, "\t{0x%04X, 0x%04X, %s},\n", lo, hi, t.expr(t.vals[lo]))
+		}
+		lo = hi + 1
+	}
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("maketables: ")
+	flag.Parse()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by maketables.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package str\n\n")
+	fmt.Fprintf(&b, "// unicodeVersion is the version of the Unicode Character Database\n")
+	fmt.Fprintf(&b, "// the tables are generated from. It is pinned, rather than following\n")
+	fmt.Fprintf(&b, "// unicode.Version, which depends on the Go toolchain building the package,\n")
+	fmt.Fprintf(&b, "// so that segmentation, normalization and display widths do not change with\n")
+	fmt.Fprintf(&b, "// the toolchain. Case mappings and white space, taken from package unicode,\n")
+	fmt.Fprintf(&b, "// follow the toolchain.\n")
+	fmt.Fprintf(&b, "const unicodeVersion = %q\n\n", unicodeVersion)
+
+	gb := newPropTable("gbCR", "gbLF", "gbControl", "gbExtend", "gbZWJ", "gbRegionalIndicator",
+		"gbPrepend", "gbSpacingMark", "gbL", "gbV", "gbT", "gbLV", "gbLVT")
+	gb.load("auxiliary/GraphemeBreakProperty.txt", "gb")
+	gb.loadFlag("emoji/emoji-data.txt", "Extended_Pictographic", "extPict")
+	gb.write(&b, "graphemeBreakTable", "graphemeBreakTable holds the Grapheme_Cluster_Break and Extended_Pictographic properties.")
+
+	wb := newPropTable("wbDoubleQuote", "wbSingleQuote", "wbHebrewLetter", "wbCR", "wbLF", "wbNewline",
+		"wbExtend", "wbRegionalIndicator", "wbFormat", "wbKatakana", "wbALetter", "wbMidLetter", "wbMidNum",
+		"wbMidNumLet", "wbNumeric", "wbExtendNumLet", "wbZWJ", "wbWSegSpace")
+	wb.load("auxiliary/WordBreakProperty.txt", "wb")
+	wb.loadFlag("emoji/emoji-data.txt", "Extended_Pictographic", "extPict")
+	wb.write(&b, "wordBreakTable", "wordBreakTable holds the Word_Break and Extended_Pictographic properties.")
+
+	sb := newPropTable("sbCR", "sbLF", "sbExtend", "sbSep", "sbFormat", "sbSp", "sbLower", "sbUpper",
+		"sbOLetter", "sbNumeric", "sbATerm", "sbSContinue", "sbSTerm", "sbClose")
+	sb.load("auxiliary/SentenceBreakProperty.txt", "sb")
+	sb.write(&b, "sentenceBreakTable", "sentenceBreakTable holds the Sentence_Break property.")
+
+	writeNormTables(&b)
+
+	width := newPropTable("widthZero", "widthWide")
+	parse("EastAsianWidth.txt", func(lo, hi rune, fields []string) {
+		if fields[0] == "W" || fields[0] == "F" {
+			for r := lo; r <= hi; r++ {
+				width.vals[r] = 2
+			}
+		}
+	})
+	// Nonspacing and enclosing marks, format and control characters take
+	// no column, whatever their East_Asian_Width.
+	parse("extracted/DerivedGeneralCategory.txt", func(lo, hi rune, fields []string) {
+		switch fields[0] {
+		case "Mn", "Me", "Cf", "Cc":
+			for r := lo; r <= hi; r++ {
+				width.vals[r] = 1
+			}
+		}
+	})
+	width.write(&b, "widthTable", "widthTable holds the display width class of code points, derived from\n// the East_Asian_Width and General_Category properties.")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// decomposition is a single level decomposition mapping.
+type decomposition struct {
+	compat bool
+	runes  []rune
+}
+
+// writeNormTables writes the tables of the normalization forms.
+func writeNormTables(w io.Writer) {
+	ccc := &propTable{vals: make([]uint16, unicode.MaxRune+1)}
+	decomps := map[rune]decomposition{}
+	parse("UnicodeData.txt", func(lo, hi rune, fields []string) {
+		c, err := strconv.Atoi(fields[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		ccc.vals[lo] = uint16(c)
+
+		if fields[4] == "" {
+			return
+		}
+		var d decomposition
+		for _, f := range strings.Fields(fields[4]) {
+			if strings.HasPrefix(f, "<") {
+				d.compat = true
+				continue
+			}
+			d.runes = append(d.runes, parseRune(f))
+		}
+		decomps[lo] = d
+	})
+	ccc.write(w, "cccTable", "cccTable holds the Canonical_Combining_Class property.")
+
+	var full func(r rune, compat bool) []rune
+	full = func(r rune, compat bool) []rune {
+		d, ok := decomps[r]
+		if !ok || d.compat && !compat {
+			return []rune{r}
+		}
+		var res []rune
+		for _, c := range d.runes {
+			res = append(res, full(c, compat)...)
+		}
+		return res
+	}
+
+	qc := newPropTable()
+	qc.loadFlag("DerivedNormalizationProps.txt", "NFD_QC; N", "qcNFDNo")
+	qc.loadFlag("DerivedNormalizationProps.txt", "NFKD_QC; N", "qcNFKDNo")
+	qc.loadFlag("DerivedNormalizationProps.txt", "NFC_QC; N", "qcNFCNo")
+	nfcMaybe := qc.loadFlag("DerivedNormalizationProps.txt", "NFC_QC; M", "qcNFCMaybe")
+	qc.loadFlag("DerivedNormalizationProps.txt", "NFKC_QC; N", "qcNFKCNo")
+	nfkcMaybe := qc.loadFlag("DerivedNormalizationProps.txt", "NFKC_QC; M", "qcNFKCMaybe")
+	qc.write(w, "normQCTable", "normQCTable holds the NFD_QC, NFKD_QC, NFC_QC and NFKC_QC properties.")
+
+	// A normalization segment cannot start with a code point whose
+	// decomposition starts with a non-starter, or in the composing forms
+	// with one which may compose with the previous character.
+	nb := newPropTable()
+	nbNFD, nbNFKD, nbNFC, nbNFKC := nb.addFlag("nbNFD"), nb.addFlag("nbNFKD"), nb.addFlag("nbNFC"), nb.addFlag("nbNFKC")
+	for r := range rune(unicode.MaxRune + 1) {
+		if d := full(r, false)[0]; ccc.vals[d] != 0 {
+			nb.vals[r] |= nbNFD | nbNFC
+		} else if qc.vals[d]&nfcMaybe != 0 {
+			nb.vals[r] |= nbNFC
+		}
+		if d := full(r, true)[0]; ccc.vals[d] != 0 {
+			nb.vals[r] |= nbNFKD | nbNFKC
+		} else if qc.vals[d]&nfkcMaybe != 0 {
+			nb.vals[r] |= nbNFKC
+		}
+	}
+	nb.write(w, "normBoundaryTable", "normBoundaryTable flags the code points which cannot start a normalization segment.")
+
+	fmt.Fprintf(w, "// decompositionTable holds the full canonical and compatibility\n")
+	fmt.Fprintf(w, "// decompositions of the code points which have one, except Hangul syllables.\n")
+	fmt.Fprintf(w, "var decompositionTable = []decompositionEntry{\n")
+	rs := slices.Sorted(maps.Keys(decomps))
+	for _, r := range rs {
+		canon := ""
+		if !decomps[r].compat {
+			canon = string(full(r, false))
+		}
+		fmt.Fprintf(w, "\t{0x%04X, %+q, %+q},\n", r, canon, string(full(r, true)))
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	exclusions := map[rune]bool{}
+	parse("DerivedNormalizationProps.txt", func(lo, hi rune, fields []string) {
+		if fields[0] == "Full_Composition_Exclusion" {
+			for r := lo; r <= hi; r++ {
+				exclusions[r] = true
+			}
+		}
+	})
+	type pair struct{ a, b, c rune }
+	var pairs []pair
+	for _, r := range rs {
+		if d := decomps[r]; !d.compat && len(d.runes) == 2 && !exclusions[r] {
+			pairs = append(pairs, pair{d.runes[0], d.runes[1], r})
+		}
+	}
+	slices.SortFunc(pairs, func(x, y pair) int {
+		return cmp.Or(cmp.Compare(x.a, y.a), cmp.Compare(x.b, y.b))
+	})
+	fmt.Fprintf(w, "// compositionTable holds the primary composites, by their canonical decomposition.\n")
+	fmt.Fprintf(w, "var compositionTable = []compositionEntry{\n")
+	for _, p := range pairs {
+		fmt.Fprintf(w, "\t{0x%04X, 0x%04X, 0x%04X},\n", p.a, p.b, p.c)
+	}
+	fmt.Fprintf(w, "}\n\n")
+}
@@ -0,0 +1,217 @@
+package str
+
+import (
+	"iter"
+	"slices"
+
+	. "github.com/rprtr258/str/internal"
+)
+
+// MatchKind selects which match a [Matcher] reports when several patterns
+// match overlapping parts of the text. Either way, matches are
+// non-overlapping and the one starting leftmost always wins.
+type MatchKind int
+
+const (
+	// LeftmostFirst prefers, among matches starting at the same position,
+	// the pattern that comes first in the dictionary, like regexp alternation.
+	LeftmostFirst MatchKind = iota
+	// LeftmostLongest prefers, among matches starting at the same position,
+	// the longest pattern.
+	LeftmostLongest
+)
+
+// Matcher finds instances of any of a dictionary of patterns in a single pass
+// over the text, using the Aho-Corasick automaton of the dictionary.
+//
+// A Matcher is immutable and safe for concurrent use by multiple goroutines.
+type Matcher struct {
+	kind MatchKind
+	// lens holds the length of each pattern.
+	lens []int
+	// classes maps each byte to its equivalence class: bytes that are not
+	// used by any pattern share a class, every other byte has its own.
+	classes [256]byte
+	// stride is the number of byte classes.
+	stride int
+	// trans is the transition table of the automaton, with failure
+	// transitions resolved: the next state from state s on byte c is
+	// trans[s*stride+classes[c]]. The start state is 0.
+	trans []int32
+	// out holds, for each state, the index of the longest pattern that is
+	// a suffix of the text consumed to reach the state, or -1.
+	out []int32
+	// depth holds the length of the text consumed to reach each state
+	// from the start state along the trie.
+	depth []int32
+}
+
+// NewMatcher returns a new Matcher searching for any of patterns, choosing
+// between overlapping matches according to kind. Patterns are identified by
+// their index in patterns. Patterns must not be empty.
+func NewMatcher(patterns []Str, kind MatchKind) *Matcher {
+	m := &Matcher{
+		kind: kind,
+		lens: make([]int, len(patterns)),
+	}
+
+	var used [256]bool
+	for i, p := range patterns {
+		Assert(p.Len > 0, "empty pattern")
+		m.lens[i] = p.Len
+		for _, c := range p.asBytes() {
+			used[c] = true
+		}
+	}
+	// Class 0 is shared by all unused bytes, if there are any.
+	if slices.Contains(used[:], false) {
+		m.stride = 1
+	}
+	for c, u := range used {
+		if u {
+			m.classes[c] = byte(m.stride)
+			m.stride++
+		}
+	}
+
+	// Build the trie, with missing transitions set to -1.
+	own := []int32{-1} // pattern spelled by each state, or -1
+	m.depth = []int32{0}
+	m.trans = make([]int32, m.stride)
+	for i := range m.trans {
+		m.trans[i] = -1
+	}
+	for i, p := range patterns {
+		s := int32(0)
+		for _, c := range p.asBytes() {
+			t := &m.trans[int(s)*m.stride+int(m.classes[c])]
+			if *t < 0 {
+				*t = int32(len(own))
+				own = append(own, -1)
+				m.depth = append(m.depth, m.depth[s]+1)
+				for range m.stride {
+					m.trans = append(m.trans, -1)
+				}
+			}
+			s = *t
+		}
+		if own[s] < 0 { // duplicates keep the first index
+			own[s] = int32(i)
+		}
+	}
+
+	// Resolve failure transitions in breadth-first order, so the failure
+	// state of every state is complete before the state itself.
+	fail := make([]int32, len(own))
+	m.out = make([]int32, len(own))
+	m.out[0] = -1
+	queue := []int32{0}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		row := m.trans[int(s)*m.stride : int(s+1)*m.stride]
+		for c, t := range row {
+			if t < 0 {
+				if s == 0 {
+					row[c] = 0
+				} else {
+					row[c] = m.trans[int(fail[s])*m.stride+c]
+				}
+				continue
+			}
+
+			if s != 0 {
+				fail[t] = m.trans[int(fail[s])*m.stride+c]
+			}
+			m.out[t] = own[t]
+			if m.out[t] < 0 {
+				m.out[t] = m.out[fail[t]]
+			}
+			queue = append(queue, t)
+		}
+	}
+	return m
+}
+
+// Find returns the index of the pattern and the location of the leftmost
+// match in s. The match itself is at s[loc[0]:loc[1]].
+// The ok result reports whether any pattern appears in s.
+func (m *Matcher) Find(s Str) (pattern int, loc [2]int, ok bool) {
+	pattern, loc = m.find(s, 0)
+	return pattern, loc, pattern >= 0
+}
+
+// Contains reports whether any pattern is within s.
+func (m *Matcher) Contains(s Str) bool {
+	p, _ := m.find(s, 0)
+	return p >= 0
+}
+
+// FindAll returns an iterator over all successive non-overlapping matches
+// in s, yielding the index of the matched pattern and its location.
+func (m *Matcher) FindAll(s Str) iter.Seq2[int, [2]int] {
+	return func(yield func(int, [2]int) bool) {
+		for at := 0; ; {
+			p, loc := m.find(s, at)
+			if p < 0 || !yield(p, loc) {
+				return
+			}
+			at = loc[1]
+		}
+	}
+}
+
+// ReplaceAll returns an iterator over the pieces of s with every match
+// replaced by with[pattern]. The pieces alternate between slices of s and
+// elements of with, and concatenate to the replaced text. Empty pieces
+// are omitted. with must have an element for every pattern.
+func (m *Matcher) ReplaceAll(s Str, with []Str) iter.Seq[Str] {
+	Assert(len(with) == len(m.lens), "replacement count does not match pattern count")
+
+	return func(yield func(Str) bool) {
+		last := 0
+		for p, loc := range m.FindAll(s) {
+			if loc[0] > last && !yield(s.Slice(last, loc[0])) {
+				return
+			}
+			if with[p].Len > 0 && !yield(with[p]) {
+				return
+			}
+			last = loc[1]
+		}
+		if last < s.Len {
+			yield(s.SliceFrom(last))
+		}
+	}
+}
+
+// find returns the leftmost match in s starting at or after at,
+// or pattern -1 if there is none.
+func (m *Matcher) find(s Str, at int) (pattern int, loc [2]int) {
+	b := s.asBytes()
+	pattern = -1
+	state := int32(0)
+	for i := at; i < len(b); i++ {
+		state = m.trans[int(state)*m.stride+int(m.classes[b[i]])]
+		end := i + 1
+
+		if p := m.out[state]; p >= 0 {
+			start := end - m.lens[p]
+			switch {
+			case pattern < 0, start < loc[0]:
+				pattern, loc = int(p), [2]int{start, end}
+			case start == loc[0]:
+				if m.kind == LeftmostLongest || int(p) < pattern {
+					pattern, loc = int(p), [2]int{start, end}
+				}
+			}
+		}
+
+		// Every match found later starts at or after end-depth,
+		// so none can beat a match starting before that.
+		if pattern >= 0 && loc[0] < end-int(m.depth[state]) {
+			break
+		}
+	}
+	return pattern, loc
+}
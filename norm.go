@@ -0,0 +1,464 @@
+package str
+
+import (
+	"iter"
+	"unicode/utf8"
+)
+
+// Form is a Unicode normalization form, as defined in
+// Unicode Standard Annex #15, https://unicode.org/reports/tr15/.
+type Form int
+
+const (
+	NFC  Form = iota // canonical decomposition followed by canonical composition
+	NFD              // canonical decomposition
+	NFKC             // compatibility decomposition followed by canonical composition
+	NFKD             // compatibility decomposition
+)
+
+// Quick check flags in normQCTable.
+const (
+	qcNFDNo = 1 << iota
+	qcNFKDNo
+	qcNFCNo
+	qcNFCMaybe
+	qcNFKCNo
+	qcNFKCMaybe
+)
+
+// Flags in normBoundaryTable.
+const (
+	nbNFD = 1 << iota
+	nbNFKD
+	nbNFC
+	nbNFKC
+)
+
+// decompositionEntry holds the full decompositions of r, nfd being
+// empty if r only has a compatibility decomposition.
+type decompositionEntry struct {
+	r         rune
+	nfd, nfkd string
+}
+
+// compositionEntry holds the primary composite c of the pair a, b.
+type compositionEntry struct {
+	a, b, c rune
+}
+
+// Hangul syllable composition constants.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// firstNormRune is the lowest code point which may be affected by
+// normalization, U+00A0 NO-BREAK SPACE having a compatibility decomposition.
+const firstNormRune = 0xA0
+
+func (f Form) compose() bool {
+	return f == NFC || f == NFKC
+}
+
+func (f Form) compat() bool {
+	return f == NFKC || f == NFKD
+}
+
+// qcMask returns the quick check flags of the code points which are not
+// known to be unchanged by f.
+func (f Form) qcMask() uint8 {
+	switch f {
+	case NFC:
+		return qcNFCNo | qcNFCMaybe
+	case NFD:
+		return qcNFDNo
+	case NFKC:
+		return qcNFKCNo | qcNFKCMaybe
+	default:
+		return qcNFKDNo
+	}
+}
+
+// nbMask returns the normBoundaryTable flag of f.
+func (f Form) nbMask() uint8 {
+	switch f {
+	case NFC:
+		return nbNFC
+	case NFD:
+		return nbNFD
+	case NFKC:
+		return nbNFKC
+	default:
+		return nbNFKD
+	}
+}
+
+// combiningClass returns the Canonical_Combining_Class of r.
+func combiningClass(r rune) uint8 {
+	if r < 0x300 {
+		return 0
+	}
+	return lookupProp(cccTable, r)
+}
+
+// decompose appends the full decomposition of r in f to buf.
+func (f Form) decompose(buf []rune, r rune) []rune {
+	if r < firstNormRune {
+		return append(buf, r)
+	}
+
+	if s := r - hangulSBase; 0 <= s && s < hangulSCount {
+		buf = append(buf, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			buf = append(buf, hangulTBase+t)
+		}
+		return buf
+	}
+
+	i, j := 0, len(decompositionTable)
+	for i < j {
+		h := int(uint(i+j) >> 1) // avoid overflow when computing h
+		if decompositionTable[h].r < r {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	if i < len(decompositionTable) && decompositionTable[i].r == r {
+		d := decompositionTable[i].nfd
+		if f.compat() {
+			d = decompositionTable[i].nfkd
+		}
+		if d != "" {
+			for _, c := range d {
+				buf = append(buf, c)
+			}
+			return buf
+		}
+	}
+	return append(buf, r)
+}
+
+// composePair returns the primary composite of a and b, if any.
+func composePair(a, b rune) (rune, bool) {
+	if l := a - hangulLBase; 0 <= l && l < hangulLCount {
+		if v := b - hangulVBase; 0 <= v && v < hangulVCount {
+			return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+		}
+		return 0, false
+	}
+	if s := a - hangulSBase; 0 <= s && s < hangulSCount && s%hangulTCount == 0 {
+		if t := b - hangulTBase; 0 < t && t < hangulTCount {
+			return a + t, true
+		}
+		return 0, false
+	}
+
+	i, j := 0, len(compositionTable)
+	for i < j {
+		h := int(uint(i+j) >> 1) // avoid overflow when computing h
+		if e := compositionTable[h]; e.a < a || e.a == a && e.b < b {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	if i < len(compositionTable) && compositionTable[i].a == a && compositionTable[i].b == b {
+		return compositionTable[i].c, true
+	}
+	return 0, false
+}
+
+// reorder sorts the runs of non-starters of buf by combining class.
+func reorder(buf []rune) {
+	for i := 1; i < len(buf); i++ {
+		r := buf[i]
+		c := combiningClass(r)
+		if c == 0 {
+			continue
+		}
+		j := i
+		for ; j > 0 && combiningClass(buf[j-1]) > c; j-- {
+			buf[j] = buf[j-1]
+		}
+		buf[j] = r
+	}
+}
+
+// composeRunes applies the canonical composition algorithm to the
+// decomposed and reordered buf.
+func composeRunes(buf []rune) []rune {
+	if len(buf) < 2 {
+		return buf
+	}
+
+	starter := -1 // index of the last starter in buf[:w]
+	if combiningClass(buf[0]) == 0 {
+		starter = 0
+	}
+	var last uint8 // combining class of buf[w-1]
+	w := 1
+	for _, r := range buf[1:] {
+		c := combiningClass(r)
+		// r is blocked from the starter by any character in between
+		// with a combining class of 0 or not lower than its own.
+		if starter >= 0 && (w == starter+1 || last != 0 && last < c) {
+			if p, ok := composePair(buf[starter], r); ok {
+				buf[starter] = p
+				continue
+			}
+		}
+		if c == 0 {
+			starter = w
+		}
+		last = c
+		buf[w] = r
+		w++
+	}
+	return buf[:w]
+}
+
+// normIter splits a string into normalization segments, each starting
+// with a character which does not interact with the preceding ones,
+// and normalizes them one at a time.
+type normIter struct {
+	f Form
+	s string
+	// i is the start of the next segment.
+	i     int
+	runes []rune
+	// buf holds the normalized form of the last segment returned by next.
+	buf []byte
+}
+
+// skipASCII skips the ASCII characters followed by ASCII characters,
+// which are segments unchanged by normalization, and returns the new position.
+func (it *normIter) skipASCII() int {
+	s, i := it.s, it.i
+	for i+1 < len(s) && s[i] < utf8.RuneSelf && s[i+1] < utf8.RuneSelf {
+		i++
+	}
+	if i+1 == len(s) && s[i] < utf8.RuneSelf {
+		i++
+	}
+	it.i = i
+	return i
+}
+
+// next returns the bounds of the next segment and whether its normalized
+// form, then held in it.buf, differs from the segment itself.
+// Each byte of an invalid UTF-8 sequence is a segment of its own,
+// left unchanged.
+func (it *normIter) next() (start, end int, changed bool) {
+	s := it.s
+	start, end = it.i, it.i
+	qc, nb := it.f.qcMask(), it.f.nbMask()
+	quick := true
+	var last uint8
+	for end < len(s) {
+		r, n := decodeRune(s[end:])
+		if r == utf8.RuneError && n == 1 {
+			if end == start {
+				end++
+			}
+			break
+		}
+		if r < firstNormRune {
+			if end > start {
+				break
+			}
+			last = 0
+		} else {
+			if end > start && lookupProp(normBoundaryTable, r)&nb == 0 {
+				break
+			}
+			c := combiningClass(r)
+			if lookupProp(normQCTable, r)&qc != 0 || c != 0 && c < last {
+				quick = false
+			}
+			last = c
+		}
+		end += n
+	}
+	it.i = end
+	if quick {
+		return start, end, false
+	}
+
+	it.runes = it.runes[:0]
+	for _, r := range s[start:end] {
+		it.runes = it.f.decompose(it.runes, r)
+	}
+	reorder(it.runes)
+	if it.f.compose() {
+		it.runes = composeRunes(it.runes)
+	}
+	it.buf = it.buf[:0]
+	for _, r := range it.runes {
+		it.buf = utf8.AppendRune(it.buf, r)
+	}
+	return start, end, string(it.buf) != s[start:end]
+}
+
+// piece returns the normalized form of the next segments, either a run of
+// unchanged segments or a changed one held in it.buf until the next call.
+// It returns "" at the end of the string.
+func (it *normIter) piece() string {
+	if start := it.i; it.skipASCII() > start {
+		return it.s[start:it.i]
+	}
+	if it.i == len(it.s) {
+		return ""
+	}
+
+	start, end, changed := it.next()
+	if changed {
+		return NewFromBytes(it.buf).String()
+	}
+	return it.s[start:end]
+}
+
+// span returns the length of the longest prefix of s unchanged by f.
+func (f Form) span(s string) int {
+	it := normIter{f: f, s: s}
+	for it.skipASCII() < len(s) {
+		if start, _, changed := it.next(); changed {
+			return start
+		}
+	}
+	return len(s)
+}
+
+// IsNormal reports whether s is in normalization form f.
+func (f Form) IsNormal(s Str) bool {
+	return f.span(s.String()) == s.Len
+}
+
+// Normalize returns s in normalization form f. Invalid UTF-8 sequences
+// are left unchanged.
+//
+// If s is already normalized, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func (f Form) Normalize(s Str, b *Builder) Str {
+	i := f.span(s.String())
+	if i == s.Len {
+		return s
+	}
+
+	return build(b, s.Len+utf8.UTFMax, func(dst []byte) []byte {
+		dst = append(dst, s.SliceTo(i).asBytes()...)
+		for p := range f.Pieces(s.SliceFrom(i)) {
+			dst = append(dst, p.asBytes()...)
+		}
+		return dst
+	})
+}
+
+// Pieces returns an iterator over consecutive pieces of s in normalization
+// form f, which concatenated make up the normalized string. The parts of s
+// already normalized are yielded as slices of s, the others are rewritten
+// into a buffer reused by the iterator: such a piece is only valid until
+// the next iteration.
+func (f Form) Pieces(s Str) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		it := normIter{f: f, s: s.String()}
+		from := 0 // start of the pending unchanged piece
+		for it.skipASCII() < s.Len {
+			start, end, changed := it.next()
+			if !changed {
+				continue
+			}
+			if from < start && !yield(s.Slice(from, start)) {
+				return
+			}
+			if !yield(NewFromBytes(it.buf)) {
+				return
+			}
+			from = end
+		}
+		if from < s.Len {
+			yield(s.SliceFrom(from))
+		}
+	}
+}
+
+// EqualNormalized reports whether s and t are equal once both are in
+// normalization form f, that is whether they are canonically equivalent
+// for NFC and NFD, or compatibility equivalent for NFKC and NFKD.
+// The strings are normalized and compared piecewise, without building
+// their normalized forms.
+func EqualNormalized(s, t Str, f Form) bool {
+	if Equal(s, t) {
+		return true
+	}
+
+	a, b := normIter{f: f, s: s.String()}, normIter{f: f, s: t.String()}
+	var x, y string
+	for {
+		if x == "" {
+			x = a.piece()
+		}
+		if y == "" {
+			y = b.piece()
+		}
+		if x == "" || y == "" {
+			return x == y
+		}
+
+		n := min(len(x), len(y))
+		if x[:n] != y[:n] {
+			return false
+		}
+		x, y = x[n:], y[n:]
+	}
+}
+
+// IndexNormalized returns the index in s of the first instance of substr
+// once both are in normalization form f, or -1 if substr is not present.
+// Matches only start and end at boundaries between normalization segments
+// of s, so that a match never leaves out combining marks applied to its
+// last character, nor takes apart a character composed in s.
+func IndexNormalized(s, substr Str, f Form) int {
+	sub := f.Normalize(substr, nil).String()
+	if sub == "" {
+		return 0
+	}
+
+	str := s.String()
+	it := normIter{f: f, s: str}
+	for start := 0; start < len(str); {
+		it.i = start
+		next := -1 // start of the second segment
+		for rest := sub; it.i < len(str); {
+			from, end, changed := it.next()
+			if next < 0 {
+				next = end
+			}
+			out := str[from:end]
+			if changed {
+				out = NewFromBytes(it.buf).String()
+			}
+
+			n := min(len(out), len(rest))
+			if out[:n] != rest[:n] {
+				break
+			}
+			if rest = rest[n:]; rest == "" {
+				if n == len(out) {
+					return start
+				}
+				break
+			}
+		}
+		start = next
+	}
+	return -1
+}
@@ -0,0 +1,223 @@
+package str
+
+import "strconv"
+
+// ParseBool returns the boolean value represented by s, as [strconv.ParseBool] does.
+func ParseBool(s Str) (bool, error) {
+	return strconv.ParseBool(s.String())
+}
+
+// ParseInt interprets s in the given base (0, 2 to 36) and bit size
+// (0 to 64) and returns the corresponding value, as [strconv.ParseInt]
+// does, base prefixes and underscores included. It does not allocate
+// unless it returns an error, which is a *[strconv.NumError].
+func ParseInt(s Str, base, bitSize int) (int64, error) {
+	return strconv.ParseInt(s.String(), base, bitSize)
+}
+
+// ParseUint is like ParseInt but for unsigned numbers, as [strconv.ParseUint].
+// A sign prefix is not permitted.
+func ParseUint(s Str, base, bitSize int) (uint64, error) {
+	return strconv.ParseUint(s.String(), base, bitSize)
+}
+
+// ParseFloat converts s to a floating-point number with the precision
+// specified by bitSize: 32 for float32, or 64 for float64, as
+// [strconv.ParseFloat] does, hexadecimal floats, underscores, infinities
+// and NaN included. It does not allocate unless it returns an error,
+// which is a *[strconv.NumError].
+func ParseFloat(s Str, bitSize int) (float64, error) {
+	return strconv.ParseFloat(s.String(), bitSize)
+}
+
+// ParseIntPrefix parses the longest prefix of s which ParseInt accepts,
+// and returns its value and the rest of s. If the prefix is out of range,
+// it returns the value and the error of ParseInt for it. If s does not
+// start with an integer, it returns the error of ParseInt for s, and s.
+func ParseIntPrefix(s Str, base, bitSize int) (int64, Str, error) {
+	n := intLen(s.String(), base, true)
+	if n == 0 {
+		i, err := ParseInt(s, base, bitSize)
+		return i, s, err
+	}
+	i, err := ParseInt(s.SliceTo(n), base, bitSize)
+	return i, s.SliceFrom(n), err
+}
+
+// ParseUintPrefix is like ParseIntPrefix but for unsigned numbers.
+func ParseUintPrefix(s Str, base, bitSize int) (uint64, Str, error) {
+	n := intLen(s.String(), base, false)
+	if n == 0 {
+		i, err := ParseUint(s, base, bitSize)
+		return i, s, err
+	}
+	i, err := ParseUint(s.SliceTo(n), base, bitSize)
+	return i, s.SliceFrom(n), err
+}
+
+// ParseFloatPrefix parses the longest prefix of s which ParseFloat
+// accepts, and returns its value and the rest of s. If the prefix is out
+// of range, it returns the value and the error of ParseFloat for it. If s
+// does not start with a number, it returns the error of ParseFloat for s,
+// and s.
+func ParseFloatPrefix(s Str, bitSize int) (float64, Str, error) {
+	n := floatLen(s.String())
+	if n == 0 {
+		f, err := ParseFloat(s, bitSize)
+		return f, s, err
+	}
+	f, err := ParseFloat(s.SliceTo(n), bitSize)
+	return f, s.SliceFrom(n), err
+}
+
+// digitVal returns the value of the digit c in bases up to 36,
+// or 36 if c is not a digit.
+func digitVal(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
+
+// digitsLen returns the length of the run of digits in base at the start
+// of s, with single underscores between them if underscores is set. If
+// prefixed is set, s follows a base prefix, which an underscore may follow.
+func digitsLen(s string, base int, underscores, prefixed bool) int {
+	i := 0
+	for i < len(s) {
+		switch {
+		case digitVal(s[i]) < base:
+			i++
+		case s[i] == '_' && underscores && (i > 0 || prefixed) && i+1 < len(s) && digitVal(s[i+1]) < base:
+			i += 2
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// basePrefix returns the base given by the prefix "0x", "0b" or "0o" of s,
+// in any case, or 0 if s has none.
+func basePrefix(s string) int {
+	if len(s) < 2 || s[0] != '0' {
+		return 0
+	}
+	switch s[1] | 0x20 {
+	case 'x':
+		return 16
+	case 'b':
+		return 2
+	case 'o':
+		return 8
+	}
+	return 0
+}
+
+// intLen returns the length of the longest prefix of s which is an integer
+// in base as accepted by strconv.ParseInt, or by strconv.ParseUint if
+// signed is not set, ignoring its range, or 0 if there is none.
+func intLen(s string, base int, signed bool) int {
+	i := 0
+	if signed && len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		i++
+	}
+	if base != 0 {
+		if base < 2 || base > 36 {
+			return 0
+		}
+		if n := digitsLen(s[i:], base, false, false); n > 0 {
+			return i + n
+		}
+		return 0
+	}
+
+	if b := basePrefix(s[i:]); b != 0 {
+		if n := digitsLen(s[i+2:], b, true, true); n > 0 {
+			return i + 2 + n
+		}
+	}
+	// A leading 0 alone is an octal prefix.
+	base = 10
+	if i < len(s) && s[i] == '0' {
+		base = 8
+	}
+	if n := digitsLen(s[i:], base, true, false); n > 0 {
+		return i + n
+	}
+	return 0
+}
+
+// floatLen returns the length of the longest prefix of s which is a number
+// as accepted by strconv.ParseFloat, ignoring its range, or 0 if there is none.
+func floatLen(s string) int {
+	i := 0
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		i++
+	}
+	switch {
+	case hasPrefixASCIIFold(s[i:], "infinity"):
+		return i + 8
+	case hasPrefixASCIIFold(s[i:], "inf"):
+		return i + 3
+	case i == 0 && hasPrefixASCIIFold(s, "nan"):
+		return 3
+	}
+
+	start := i
+	base, exp := 10, byte('e')
+	if basePrefix(s[i:]) == 16 {
+		base, exp = 16, 'p'
+		i += 2
+	}
+	n := digitsLen(s[i:], base, true, base == 16)
+	i += n
+	if i < len(s) && s[i] == '.' {
+		frac := digitsLen(s[i+1:], base, true, false)
+		i += 1 + frac
+		n += frac
+	}
+
+	end := i
+	if i < len(s) && s[i]|0x20 == exp {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if e := digitsLen(s[i:], 10, true, false); e > 0 {
+			end = i + e
+		} else if base == 16 {
+			end = 0
+		}
+	} else if base == 16 {
+		// A hexadecimal mantissa needs an exponent.
+		end = 0
+	}
+	switch {
+	case n > 0 && end > 0:
+		return end
+	case base == 16:
+		// The 0 of the prefix alone.
+		return start + 1
+	}
+	return 0
+}
+
+// hasPrefixASCIIFold reports whether s begins with the ASCII lower case prefix,
+// ignoring case.
+func hasPrefixASCIIFold(s, prefix string) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if s[i]|0x20 != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,591 @@
+package str
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/rprtr258/str/internal"
+)
+
+// Unquote interprets s as a single-quoted, double-quoted or backquoted Go
+// string literal, and returns the string value that s quotes, as
+// [strconv.Unquote] does, with the same errors. It is the inverse of
+// AppendQuote. If s has no escapes, the result is a slice of s and no
+// allocation is made. Otherwise it is finished in b, or built in a newly
+// allocated buffer if b is nil.
+func Unquote(s Str, b *Builder) (Str, error) {
+	str := s.String()
+	if len(str) < 2 || str[0] != str[len(str)-1] {
+		return empty, strconv.ErrSyntax
+	}
+	quote, in := str[0], str[1:len(str)-1]
+
+	switch quote {
+	case '`':
+		if strings.IndexByte(in, '`') >= 0 {
+			return empty, strconv.ErrSyntax
+		}
+		if strings.IndexByte(in, '\r') < 0 {
+			return s.Slice(1, s.Len-1), nil
+		}
+		// Carriage returns are discarded from raw strings.
+		return build(b, len(in), func(dst []byte) []byte {
+			for i := 0; i < len(in); i++ {
+				if in[i] != '\r' {
+					dst = append(dst, in[i])
+				}
+			}
+			return dst
+		}), nil
+	case '"', '\'':
+		if strings.IndexByte(in, '\n') >= 0 {
+			return empty, strconv.ErrSyntax
+		}
+		if strings.IndexByte(in, '\\') < 0 {
+			var valid bool
+			if quote == '"' {
+				valid = strings.IndexByte(in, '"') < 0 && utf8.ValidString(in)
+			} else {
+				r, n := utf8.DecodeRuneInString(in)
+				valid = n == len(in) && r != '\'' && (r != utf8.RuneError || n != 1)
+			}
+			if valid {
+				return s.Slice(1, s.Len-1), nil
+			}
+		}
+
+		return tryBuild(b, len(in), func(dst []byte) ([]byte, error) {
+			for len(in) > 0 {
+				r, multibyte, tail, err := strconv.UnquoteChar(in, quote)
+				if err != nil {
+					return nil, err
+				}
+				in = tail
+				if r < utf8.RuneSelf || !multibyte {
+					dst = append(dst, byte(r))
+				} else {
+					dst = utf8.AppendRune(dst, r)
+				}
+				if quote == '\'' && len(in) > 0 {
+					// A character literal has a single character.
+					return nil, strconv.ErrSyntax
+				}
+			}
+			return dst, nil
+		})
+	}
+	return empty, strconv.ErrSyntax
+}
+
+// QuoteJSON returns s as a double-quoted JSON string. Quotes, backslashes
+// and control characters are escaped, as well as U+2028 LINE SEPARATOR and
+// U+2029 PARAGRAPH SEPARATOR, which JavaScript does not accept in strings.
+// Invalid UTF-8 is replaced by U+FFFD. HTML characters are not escaped.
+// The result is finished in b, or built in a newly allocated buffer if b is nil.
+func QuoteJSON(s Str, b *Builder) Str {
+	return build(b, s.Len+2, func(dst []byte) []byte {
+		str := s.String()
+		dst = append(dst, '"')
+		start := 0
+		for i := 0; i < len(str); {
+			c := str[i]
+			var esc string
+			n := 1
+			switch {
+			case c >= utf8.RuneSelf:
+				var r rune
+				r, n = utf8.DecodeRuneInString(str[i:])
+				switch {
+				case r == utf8.RuneError && n == 1:
+					esc = `\ufffd`
+				case r == '\u2028':
+					esc = `\u2028`
+				case r == '\u2029':
+					esc = `\u2029`
+				}
+			case c == '"':
+				esc = `\"`
+			case c == '\\':
+				esc = `\\`
+			case c == '\b':
+				esc = `\b`
+			case c == '\f':
+				esc = `\f`
+			case c == '\n':
+				esc = `\n`
+			case c == '\r':
+				esc = `\r`
+			case c == '\t':
+				esc = `\t`
+			case c < 0x20:
+				dst = append(dst, str[start:i]...)
+				dst = append(dst, '\\', 'u', '0', '0', UpperHex[c>>4], UpperHex[c&0xF])
+				i++
+				start = i
+				continue
+			}
+			if esc == "" {
+				i += n
+				continue
+			}
+			dst = append(dst, str[start:i]...)
+			dst = append(dst, esc...)
+			i += n
+			start = i
+		}
+		dst = append(dst, str[start:]...)
+		return append(dst, '"')
+	})
+}
+
+// UnquoteJSON interprets s as a double-quoted JSON string and returns its
+// value, as [encoding/json] decodes it: invalid UTF-8 and unpaired surrogate
+// escapes are replaced by U+FFFD. It returns [strconv.ErrSyntax] if s is not
+// a valid JSON string. If s has no escapes, the result is a slice of s and
+// no allocation is made. Otherwise it is finished in b, or built in a newly
+// allocated buffer if b is nil.
+func UnquoteJSON(s Str, b *Builder) (Str, error) {
+	str := s.String()
+	if len(str) < 2 || str[0] != '"' || str[len(str)-1] != '"' {
+		return empty, strconv.ErrSyntax
+	}
+	in := str[1 : len(str)-1]
+
+	i := 0
+	for i < len(in) {
+		c := in[i]
+		if c == '\\' || c == '"' || c < 0x20 {
+			break
+		}
+		if c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(in[i:])
+		if r == utf8.RuneError && n == 1 {
+			break
+		}
+		i += n
+	}
+	if i == len(in) {
+		return s.Slice(1, s.Len-1), nil
+	}
+
+	return tryBuild(b, len(in), func(dst []byte) ([]byte, error) {
+		dst, ok := AppendUnquoteJSON(append(dst, in[:i]...), in[i:])
+		if !ok {
+			return nil, strconv.ErrSyntax
+		}
+		return dst, nil
+	})
+}
+
+// isShellSafe reports whether c needs no quoting in a POSIX shell word.
+func isShellSafe(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("@%+=:,./_-", c) >= 0
+}
+
+// QuoteShell returns s quoted as a single word for a POSIX shell. If s is
+// not empty and has only letters, digits and characters among @%+=:,./_-,
+// it is returned without allocating. Otherwise it is enclosed in single
+// quotes, with single quotes written as '\”, and the result is finished
+// in b, or built in a newly allocated buffer if b is nil.
+func QuoteShell(s Str, b *Builder) Str {
+	str := s.String()
+	safe := len(str) > 0
+	for i := 0; i < len(str) && safe; i++ {
+		safe = isShellSafe(str[i])
+	}
+	if safe {
+		return s
+	}
+
+	return build(b, len(str)+2, func(dst []byte) []byte {
+		dst = append(dst, '\'')
+		for i := 0; i < len(str); i++ {
+			if str[i] == '\'' {
+				dst = append(dst, `'\''`...)
+			} else {
+				dst = append(dst, str[i])
+			}
+		}
+		return append(dst, '\'')
+	})
+}
+
+// UnquoteShell interprets s as a single POSIX shell word and returns its
+// value once quotes are removed: the contents of single quotes are taken
+// literally, backslashes escape the next character outside quotes, and
+// within double quotes only before $, `, ", \ and newline, and escaped
+// newlines are removed. No expansion is performed: $ and ` are kept as
+// they are. It returns [strconv.ErrSyntax] if a quote is not closed, s
+// ends with an escaping backslash, or has unquoted blanks or any of the
+// operator characters |&;<>(). If s has no quoting, or is a single quoted
+// string without escapes, the result is a slice of s and no allocation is
+// made. Otherwise it is finished in b, or built in a newly allocated buffer
+// if b is nil.
+func UnquoteShell(s Str, b *Builder) (Str, error) {
+	str := s.String()
+	if i := strings.IndexAny(str, "'\"\\ \t\n|&;<>()"); i < 0 {
+		return s, nil
+	}
+	if n := len(str); n >= 2 {
+		switch {
+		case str[0] == '\'' && str[n-1] == '\'' && strings.IndexByte(str[1:n-1], '\'') < 0,
+			str[0] == '"' && str[n-1] == '"' && strings.IndexAny(str[1:n-1], "\"\\") < 0:
+			return s.Slice(1, n-1), nil
+		}
+	}
+
+	return tryBuild(b, len(str), func(dst []byte) ([]byte, error) {
+		for i := 0; i < len(str); i++ {
+			switch c := str[i]; c {
+			case '\'':
+				end := strings.IndexByte(str[i+1:], '\'')
+				if end < 0 {
+					return nil, strconv.ErrSyntax
+				}
+				dst = append(dst, str[i+1:i+1+end]...)
+				i += 1 + end
+			case '"':
+				for i++; ; i++ {
+					if i == len(str) {
+						return nil, strconv.ErrSyntax
+					}
+					c := str[i]
+					if c == '"' {
+						break
+					}
+					if c == '\\' && i+1 < len(str) && strings.IndexByte("$`\"\\\n", str[i+1]) >= 0 {
+						i++
+						c = str[i]
+						if c == '\n' {
+							continue
+						}
+					}
+					dst = append(dst, c)
+				}
+			case '\\':
+				if i+1 == len(str) {
+					return nil, strconv.ErrSyntax
+				}
+				i++
+				if str[i] != '\n' {
+					dst = append(dst, str[i])
+				}
+			case ' ', '\t', '\n', '|', '&', ';', '<', '>', '(', ')':
+				return nil, strconv.ErrSyntax
+			default:
+				dst = append(dst, c)
+			}
+		}
+		return dst, nil
+	})
+}
+
+// htmlEscapes holds the escapes of EscapeHTML, as those of [html.EscapeString].
+var htmlEscapes = [...]string{
+	'&':  "&amp;",
+	'\'': "&#39;",
+	'<':  "&lt;",
+	'>':  "&gt;",
+	'"':  "&#34;",
+}
+
+// EscapeHTML returns s with the special characters <, >, &, ' and "
+// escaped as HTML entities, as [html.EscapeString] does. If s has none of
+// them, it is returned without allocating. Otherwise the result is finished
+// in b, or built in a newly allocated buffer if b is nil.
+func EscapeHTML(s Str, b *Builder) Str {
+	str := s.String()
+	i := strings.IndexAny(str, `&'<>"`)
+	if i < 0 {
+		return s
+	}
+
+	return build(b, len(str)+8, func(dst []byte) []byte {
+		dst = append(dst, str[:i]...)
+		for ; i < len(str); i++ {
+			c := str[i]
+			if int(c) < len(htmlEscapes) && htmlEscapes[c] != "" {
+				dst = append(dst, htmlEscapes[c]...)
+			} else {
+				dst = append(dst, c)
+			}
+		}
+		return dst
+	})
+}
+
+// UnescapeHTML returns s with its HTML entities, such as "&lt;" or "&#233;",
+// replaced by the characters they stand for, as [html.UnescapeString] does.
+// If s has no entities, it is returned without allocating. Otherwise the
+// result is finished in b, or built in a newly allocated buffer if b is nil.
+func UnescapeHTML(s Str, b *Builder) Str {
+	str := s.String()
+	i := strings.IndexByte(str, '&')
+	if i < 0 {
+		return s
+	}
+
+	return build(b, len(str), func(dst []byte) []byte {
+		start := len(dst)
+		dst = append(dst, str[:i]...)
+		for i < len(str) {
+			if str[i] != '&' {
+				dst = append(dst, str[i])
+				i++
+				continue
+			}
+			r, n := htmlEntity(str[i:])
+			if n == 0 {
+				// A rare entity: leave it all to package html.
+				return append(dst[:start], html.UnescapeString(str)...)
+			}
+			dst = utf8.AppendRune(dst, r)
+			i += n
+		}
+		return dst
+	})
+}
+
+// htmlEntity returns the character of the common entity at the start of s,
+// which starts with '&', and its length, or 0 if it is not such an entity.
+// Common entities are those of the characters escaped by EscapeHTML and the
+// numeric character references, ended by a semicolon, of the characters
+// which package html does not replace.
+func htmlEntity(s string) (rune, int) {
+	for _, e := range [...]struct {
+		name string
+		r    rune
+	}{{"&amp;", '&'}, {"&lt;", '<'}, {"&gt;", '>'}, {"&quot;", '"'}, {"&apos;", '\''}} {
+		if strings.HasPrefix(s, e.name) {
+			return e.r, len(e.name)
+		}
+	}
+
+	if len(s) < 3 || s[1] != '#' {
+		return 0, 0
+	}
+	i, base := 2, rune(10)
+	if s[i] == 'x' || s[i] == 'X' {
+		i, base = 3, 16
+	}
+	var r rune
+	for start := i; i < len(s) && i-start < 7; i++ {
+		d := rune(digitVal(s[i]))
+		if d >= base {
+			break
+		}
+		r = r*base + d
+	}
+	if i == len(s) || s[i] != ';' || s[i-1] == '#' || s[i-1]|0x20 == 'x' {
+		return 0, 0
+	}
+	if r == 0 || 0x80 <= r && r <= 0x9F || IsSurrogate(r) || r > utf8.MaxRune {
+		return 0, 0
+	}
+	return r, i + 1
+}
+
+// EscapeURL returns s with all the bytes but the unreserved characters of
+// RFC 3986, letters, digits and -._~, percent-encoded as %XX. The result is
+// suitable for any component of a URL. If s has only unreserved characters,
+// it is returned without allocating. Otherwise the result is finished in b,
+// or built in a newly allocated buffer if b is nil.
+func EscapeURL(s Str, b *Builder) Str {
+	str := s.String()
+	i := 0
+	for i < len(str) && IsUnreserved(str[i]) {
+		i++
+	}
+	if i == len(str) {
+		return s
+	}
+
+	return build(b, len(str)+8, func(dst []byte) []byte {
+		dst = append(dst, str[:i]...)
+		for ; i < len(str); i++ {
+			if c := str[i]; IsUnreserved(c) {
+				dst = append(dst, c)
+			} else {
+				dst = append(dst, '%', UpperHex[c>>4], UpperHex[c&0xF])
+			}
+		}
+		return dst
+	})
+}
+
+// UnescapeURL returns s with its percent-encoded bytes %XX decoded, in either
+// case. Plus signs are not decoded as spaces. It returns [strconv.ErrSyntax]
+// if a % is not followed by two hexadecimal digits. If s has no escapes,
+// it is returned without allocating. Otherwise the result is finished in b,
+// or built in a newly allocated buffer if b is nil.
+func UnescapeURL(s Str, b *Builder) (Str, error) {
+	str := s.String()
+	i := strings.IndexByte(str, '%')
+	if i < 0 {
+		return s, nil
+	}
+
+	return tryBuild(b, len(str), func(dst []byte) ([]byte, error) {
+		dst = append(dst, str[:i]...)
+		for ; i < len(str); i++ {
+			c := str[i]
+			if c == '%' {
+				if i+2 >= len(str) || !IsHex(str[i+1]) || !IsHex(str[i+2]) {
+					return nil, strconv.ErrSyntax
+				}
+				c = Unhex(str[i+1])<<4 | Unhex(str[i+2])
+				i += 2
+			}
+			dst = append(dst, c)
+		}
+		return dst, nil
+	})
+}
+
+// EscapeC returns s escaped for a double-quoted C string literal, without
+// the quotes: backslashes, quotes and control characters are escaped, the
+// latter as \a, \b, \f, \n, \r, \t, \v, or as 3 octal digits like all the
+// bytes outside of ASCII. If s has only printable ASCII characters other
+// than backslashes and quotes, it is returned without allocating. Otherwise
+// the result is finished in b, or built in a newly allocated buffer if b is nil.
+func EscapeC(s Str, b *Builder) Str {
+	str := s.String()
+	i := 0
+	for i < len(str) && str[i] >= 0x20 && str[i] < 0x7F && str[i] != '\\' && str[i] != '"' && str[i] != '\'' {
+		i++
+	}
+	if i == len(str) {
+		return s
+	}
+
+	return build(b, len(str)+8, func(dst []byte) []byte {
+		dst = append(dst, str[:i]...)
+		for ; i < len(str); i++ {
+			switch c := str[i]; c {
+			case '\\', '"', '\'':
+				dst = append(dst, '\\', c)
+			case '\a':
+				dst = append(dst, '\\', 'a')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\v':
+				dst = append(dst, '\\', 'v')
+			default:
+				if c >= 0x20 && c < 0x7F {
+					dst = append(dst, c)
+				} else {
+					dst = append(dst, '\\', '0'+c>>6, '0'+c>>3&7, '0'+c&7)
+				}
+			}
+		}
+		return dst
+	})
+}
+
+// UnescapeC returns s with its C escape sequences replaced by the bytes they
+// stand for: the simple escapes \a, \b, \f, \n, \r, \t, \v, \\, \', \" and \?,
+// 1 to 3 octal digits, \x followed by hexadecimal digits, and the UTF-8
+// encoding of the characters given by \u and 4 hexadecimal digits or \U and 8.
+// It returns [strconv.ErrSyntax] for other escapes and for values out of
+// range. If s has no escapes, it is returned without allocating. Otherwise
+// the result is finished in b, or built in a newly allocated buffer if b is nil.
+func UnescapeC(s Str, b *Builder) (Str, error) {
+	str := s.String()
+	i := strings.IndexByte(str, '\\')
+	if i < 0 {
+		return s, nil
+	}
+
+	return tryBuild(b, len(str), func(dst []byte) ([]byte, error) {
+		dst = append(dst, str[:i]...)
+		for i < len(str) {
+			if str[i] != '\\' {
+				dst = append(dst, str[i])
+				i++
+				continue
+			}
+
+			if i+1 == len(str) {
+				return nil, strconv.ErrSyntax
+			}
+			c := str[i+1]
+			i += 2
+			switch c {
+			case 'a':
+				dst = append(dst, '\a')
+			case 'b':
+				dst = append(dst, '\b')
+			case 'f':
+				dst = append(dst, '\f')
+			case 'n':
+				dst = append(dst, '\n')
+			case 'r':
+				dst = append(dst, '\r')
+			case 't':
+				dst = append(dst, '\t')
+			case 'v':
+				dst = append(dst, '\v')
+			case '\\', '\'', '"', '?':
+				dst = append(dst, c)
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := int(c - '0')
+				for n := 1; n < 3 && i < len(str) && '0' <= str[i] && str[i] <= '7'; n++ {
+					v = v<<3 | int(str[i]-'0')
+					i++
+				}
+				if v > 0xFF {
+					return nil, strconv.ErrSyntax
+				}
+				dst = append(dst, byte(v))
+			case 'x':
+				v, n := 0, 0
+				for ; i < len(str) && digitVal(str[i]) < 16; i++ {
+					v = v<<4 | digitVal(str[i])
+					if n++; v > 0xFF {
+						return nil, strconv.ErrSyntax
+					}
+				}
+				if n == 0 {
+					return nil, strconv.ErrSyntax
+				}
+				dst = append(dst, byte(v))
+			case 'u', 'U':
+				n := 4
+				if c == 'U' {
+					n = 8
+				}
+				if i+n > len(str) {
+					return nil, strconv.ErrSyntax
+				}
+				var r rune
+				for _, d := range []byte(str[i : i+n]) {
+					if digitVal(d) >= 16 {
+						return nil, strconv.ErrSyntax
+					}
+					r = r<<4 | rune(digitVal(d))
+				}
+				if !utf8.ValidRune(r) {
+					return nil, strconv.ErrSyntax
+				}
+				dst = utf8.AppendRune(dst, r)
+				i += n
+			default:
+				return nil, strconv.ErrSyntax
+			}
+		}
+		return dst, nil
+	})
+}
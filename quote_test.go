@@ -0,0 +1,83 @@
+package str
+
+import (
+	"strconv"
+	"testing"
+	"unicode/utf8"
+)
+
+var quoteSeeds = []string{
+	"", "hello", "it's", `"quoted"`, `back\slash`, "tab\tnew\nline\r",
+	"\x00\x01\x7f\xff", "héllo, 世界", "  ", "\U0001F600",
+	"a b|c&d;e<f>g(h)", "$HOME `cmd`", "&amp;&lt;&#233;&#x41;", "100% a+b=c",
+	`é\x41\101\?`, `"😀"`, "'\\''",
+}
+
+// fuzzRoundTrip checks that decode reverses encode on the fuzzed strings and
+// on the values decode returns for them, when valid reports that they are
+// encoded without loss.
+func fuzzRoundTrip(f *testing.F, encode func(Str, *Builder) Str, decode func(Str, *Builder) (Str, error), valid func(string) bool) {
+	for _, s := range quoteSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		roundTrip := func(s string) {
+			enc := encode(NewFromString(s), nil)
+			got, err := decode(enc, nil)
+			if err != nil || got.String() != s {
+				t.Errorf("decode(%q) = %q, %v, want %q", enc.String(), got.String(), err, s)
+			}
+		}
+		if valid(s) {
+			roundTrip(s)
+		}
+		if v, err := decode(NewFromString(s), nil); err == nil && valid(v.String()) {
+			roundTrip(v.String())
+		}
+	})
+}
+
+func anyString(string) bool { return true }
+
+func FuzzQuote(f *testing.F) {
+	fuzzRoundTrip(f, AppendQuote, Unquote, anyString)
+}
+
+func FuzzUnquote(f *testing.F) {
+	for _, s := range quoteSeeds {
+		f.Add(s)
+		f.Add(strconv.Quote(s))
+		f.Add("`" + s + "`")
+	}
+	f.Add(`'a'`)
+	f.Add(`'\''`)
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := Unquote(NewFromString(s), nil)
+		want, wantErr := strconv.Unquote(s)
+		if err != wantErr || got.String() != want {
+			t.Errorf("Unquote(%q) = %q, %v, want %q, %v", s, got.String(), err, want, wantErr)
+		}
+	})
+}
+
+func FuzzQuoteJSON(f *testing.F) {
+	// Invalid UTF-8 is replaced by QuoteJSON.
+	fuzzRoundTrip(f, QuoteJSON, UnquoteJSON, utf8.ValidString)
+}
+
+func FuzzQuoteShell(f *testing.F) {
+	fuzzRoundTrip(f, QuoteShell, UnquoteShell, anyString)
+}
+
+func FuzzEscapeHTML(f *testing.F) {
+	unescape := func(s Str, b *Builder) (Str, error) { return UnescapeHTML(s, b), nil }
+	fuzzRoundTrip(f, EscapeHTML, unescape, anyString)
+}
+
+func FuzzEscapeURL(f *testing.F) {
+	fuzzRoundTrip(f, EscapeURL, UnescapeURL, anyString)
+}
+
+func FuzzEscapeC(f *testing.F) {
+	fuzzRoundTrip(f, EscapeC, UnescapeC, anyString)
+}
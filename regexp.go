@@ -1,89 +1,328 @@
 package str
 
-// TODO: lots of allocations, (*Regexp).allMatches is not available
-
 import (
 	"iter"
 	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"sync"
+	"unicode/utf8"
 )
 
-// Regexp is a wrapper around regexp.Regexp
-type Regexp struct{ regexp.Regexp }
+// Regexp is a compiled regular expression, with the syntax and semantics of
+// package regexp, matching Str values. Matches are returned as views into
+// the searched string. A Regexp is safe for concurrent use by multiple
+// goroutines.
+//
+// Matching runs on an in-package machine whose state is pooled, so that
+// matching does not allocate in the steady state, and iterating over all
+// matches reuses a single machine.
+type Regexp struct {
+	re   *regexp.Regexp
+	prog *syntax.Prog
+	// cond holds the empty-width conditions required at the start of a match.
+	cond syntax.EmptyOp
+	// prefix is the literal every match starts with, if any.
+	prefix     string
+	prefixRune rune
+	machines   sync.Pool
+}
 
-// FindIndex returns a two-element slice of integers defining the location of
-// the leftmost match in b of the regular expression. The match itself is at
-// b[loc[0]:loc[1]].
-// A return value of nil indicates no match.
-func (re *Regexp) FindIndex(b []byte) (loc [2]int, ok bool) {
-	res := re.Regexp.FindIndex(b)
-	if res == nil {
-		return [2]int{}, false
+// Compile parses a regular expression and returns, if successful,
+// a Regexp that can be used to match against text.
+// See [regexp.Compile] for the syntax and the leftmost-first semantics.
+func Compile(expr string) (*Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	// Parse again for the program, with the flags used by regexp.Compile.
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, err
 	}
-	return [2]int(res), true
+
+	res := &Regexp{re: re, prog: prog, cond: prog.StartCond()}
+	if prefix, _ := prog.Prefix(); prefix != "" {
+		res.prefix = prefix
+		res.prefixRune, _ = utf8.DecodeRuneInString(prefix)
+	}
+	return res, nil
 }
 
-// FindStringIndex returns a two-element slice of integers defining the
-// location of the leftmost match in s of the regular expression. The match
-// itself is at s[loc[0]:loc[1]].
-// A return value of nil indicates no match.
-func (re *Regexp) FindStringIndex(s Str) (loc [2]int, ok bool) {
-	res := re.Regexp.FindStringIndex(s.String())
-	if res == nil {
+// get returns a machine recording ncap capture positions.
+func (re *Regexp) get(ncap int) *machine {
+	m, ok := re.machines.Get().(*machine)
+	if !ok {
+		m = newMachine(re)
+	}
+	m.init(ncap)
+	return m
+}
+
+func (re *Regexp) put(m *machine) {
+	re.machines.Put(m)
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+func MustCompile(expr string) *Regexp {
+	re, err := Compile(expr)
+	if err != nil {
+		panic("str: Compile(" + strconv.Quote(expr) + "): " + err.Error())
+	}
+	return re
+}
+
+// String returns the source text used to compile the regular expression.
+func (re *Regexp) String() string {
+	return re.re.String()
+}
+
+// NumSubexp returns the number of parenthesized subexpressions.
+func (re *Regexp) NumSubexp() int {
+	return re.re.NumSubexp()
+}
+
+// SubexpNames returns the names of the parenthesized subexpressions,
+// as [regexp.Regexp.SubexpNames] does.
+func (re *Regexp) SubexpNames() []string {
+	return re.re.SubexpNames()
+}
+
+// SubexpIndex returns the index of the first subexpression with the given
+// name, or -1 if there is no subexpression with that name.
+func (re *Regexp) SubexpIndex(name string) int {
+	return re.re.SubexpIndex(name)
+}
+
+// Match reports whether s contains any match of the regular expression.
+func (re *Regexp) Match(s Str) bool {
+	m := re.get(0)
+	defer re.put(m)
+	return m.match(s.String(), 0)
+}
+
+// FindIndex returns the location of the leftmost match in s of the regular
+// expression. The match itself is at s.Slice(loc[0], loc[1]).
+func (re *Regexp) FindIndex(s Str) (loc [2]int, ok bool) {
+	m := re.get(2)
+	defer re.put(m)
+	if !m.match(s.String(), 0) {
 		return [2]int{}, false
 	}
-	return [2]int(res), true
+	return [2]int(m.matchcap), true
 }
 
-// FindString returns a string holding the text of the leftmost match in s of the regular
-// expression. If there is no match, the return value is an empty string,
-// but it will also be empty if the regular expression successfully matches
-// an empty string. Use [Regexp.FindStringIndex] or [Regexp.FindStringSubmatch] if it is
-// necessary to distinguish these cases.
-func (re *Regexp) FindString(s Str) Str {
-	loc, ok := re.FindStringIndex(s)
+// Find returns the leftmost match in s of the regular expression,
+// and whether there is one.
+func (re *Regexp) Find(s Str) (Str, bool) {
+	loc, ok := re.FindIndex(s)
 	if !ok {
-		return empty
+		return empty, false
 	}
-	return s.Slice(loc[0], loc[1])
+	return s.Slice(loc[0], loc[1]), true
 }
 
-// FindSubmatchIndex returns a slice holding the index pairs identifying the
-// leftmost match of the regular expression in b and the matches, if any, of
-// its subexpressions, as defined by the 'Submatch' and 'Index' descriptions
-// in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindSubmatchIndex(b []byte) ([2]int, bool) {
-	res := re.Regexp.FindSubmatchIndex(b)
-	if res == nil {
-		return [2]int{}, false
+// AllIndex returns an iterator over the locations of all successive
+// non-overlapping matches of the regular expression in s, as defined by
+// the 'All' description in the package regexp documentation.
+// It does not allocate per match.
+func (re *Regexp) AllIndex(s Str) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		m := re.get(2)
+		defer re.put(m)
+		m.all(s.String(), func(cap []int) bool {
+			return yield(cap[0], cap[1])
+		})
 	}
-	return [2]int(res), true
 }
 
-// FindStringSubmatch returns a slice of strings holding the text of the
-// leftmost match of the regular expression in s and the matches, if any, of
-// its subexpressions, as defined by the 'Submatch' description in the
-// package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindStringSubmatch(s Str) iter.Seq[Str] {
+// FindAll returns an iterator over all successive non-overlapping matches
+// of the regular expression in s.
+func (re *Regexp) FindAll(s Str) iter.Seq[Str] {
 	return func(yield func(Str) bool) {
-		for _, loc := range re.FindAllStringSubmatchIndex(s.String(), -1) {
-			if !yield(s.Slice(loc[0], loc[1])) {
+		for start, end := range re.AllIndex(s) {
+			if !yield(s.Slice(start, end)) {
 				return
 			}
 		}
 	}
 }
 
-// FindAll is the 'All' version of Find; it returns a slice of all successive
-// matches of the expression, as defined by the 'All' description in the
-// package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAll(b []byte, n int) [][2]byte {
-	res := re.Regexp.FindAll(b, n)
-	result := make([][2]byte, len(res))
-	for i, v := range res {
-		result[i] = [2]byte(v)
+// Match holds the location of a match of a regular expression and of its
+// subexpressions. The memory of a Match is reused when it is passed again
+// to [Regexp.FindSubmatch].
+type Match struct {
+	re *Regexp
+	s  Str
+	// loc holds the index pairs of the match and of the subexpressions,
+	// -1 for those which did not participate in the match.
+	loc []int
+}
+
+// FindSubmatch finds the leftmost match in s of the regular expression and
+// the matches of its subexpressions, stores them in m and reports whether
+// there is a match.
+func (re *Regexp) FindSubmatch(s Str, m *Match) bool {
+	mach := re.get(re.prog.NumCap)
+	defer re.put(mach)
+	if !mach.match(s.String(), 0) {
+		return false
+	}
+	m.set(re, s, mach.matchcap)
+	return true
+}
+
+// AllSubmatch returns an iterator over all successive non-overlapping
+// matches of the regular expression in s, with the matches of their
+// subexpressions. The yielded Match is reused by the iterator: it is only
+// valid until the next iteration. It does not allocate per match.
+func (re *Regexp) AllSubmatch(s Str) iter.Seq[*Match] {
+	return func(yield func(*Match) bool) {
+		mach := re.get(re.prog.NumCap)
+		defer re.put(mach)
+		var m Match
+		mach.all(s.String(), func(cap []int) bool {
+			m.set(re, s, cap)
+			return yield(&m)
+		})
+	}
+}
+
+func (m *Match) set(re *Regexp, s Str, loc []int) {
+	m.re, m.s = re, s
+	m.loc = append(m.loc[:0], loc...)
+}
+
+// Index returns the location of the whole match.
+func (m *Match) Index() (start, end int) {
+	return m.loc[0], m.loc[1]
+}
+
+// NumGroups returns the number of groups of the match: the whole match,
+// with index 0, and the parenthesized subexpressions.
+func (m *Match) NumGroups() int {
+	return len(m.loc) / 2
+}
+
+// GroupIndex returns the location of group i, and whether the group
+// participated in the match.
+func (m *Match) GroupIndex(i int) (start, end int, ok bool) {
+	start, end = m.loc[2*i], m.loc[2*i+1]
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Group returns the text matched by group i, group 0 being the whole match,
+// or an empty string if the group did not participate in the match.
+func (m *Match) Group(i int) Str {
+	start, end, ok := m.GroupIndex(i)
+	if !ok {
+		return empty
+	}
+	return m.s.Slice(start, end)
+}
+
+// Named returns the text matched by the first subexpression named name,
+// or an empty string if there is no such subexpression or if it did not
+// participate in the match.
+func (m *Match) Named(name string) Str {
+	i := m.re.SubexpIndex(name)
+	if i < 0 {
+		return empty
+	}
+	return m.Group(i)
+}
+
+// Expand returns template with its variables replaced by the corresponding
+// groups of the match, as [regexp.Regexp.Expand] does: $1 or ${1} for group
+// 1 and $name or ${name} for named groups.
+// The result is finished in b, or built in a newly allocated buffer if b is nil.
+func (m *Match) Expand(template Str, b *Builder) Str {
+	return build(b, template.Len, func(dst []byte) []byte {
+		return m.re.re.ExpandString(dst, template.String(), m.s.String(), m.loc)
+	})
+}
+
+// Split slices s into substrings separated by the matches of the regular
+// expression, with the semantics of [regexp.Regexp.Split]:
+//
+//	n > 0: at most n substrings; the last substring will be the unsplit remainder.
+//	n == 0: the result is nil (zero substrings)
+//	n < 0: all substrings
+func (re *Regexp) Split(s Str, n int) iter.Seq[Str] {
+	if n == 0 {
+		return nil
+	}
+
+	return func(yield func(Str) bool) {
+		if re.re.String() != "" && s.Len == 0 {
+			yield(s)
+			return
+		}
+
+		beg, end, count := 0, 0, 0
+		for start, stop := range re.AllIndex(s) {
+			if n > 0 && count == n-1 {
+				break
+			}
+			end = start
+			if stop != 0 {
+				if !yield(s.Slice(beg, end)) {
+					return
+				}
+				count++
+			}
+			beg = stop
+		}
+		if end != s.Len {
+			yield(s.SliceFrom(beg))
+		}
+	}
+}
+
+// replace returns s with each match replaced by the string appended by repl,
+// or s itself if there is no match.
+func (re *Regexp) replace(s Str, b *Builder, repl func(dst []byte, m *Match) []byte) Str {
+	if !re.Match(s) {
+		return s
 	}
-	return result
+
+	return build(b, s.Len, func(dst []byte) []byte {
+		str, last := s.String(), 0
+		for m := range re.AllSubmatch(s) {
+			start, end := m.Index()
+			dst = append(dst, str[last:start]...)
+			dst = repl(dst, m)
+			last = end
+		}
+		return append(dst, str[last:]...)
+	})
+}
+
+// ReplaceAll returns s with the matches of the regular expression replaced by
+// template, in which variables are expanded as by [Match.Expand].
+// If there is no match, s is returned without allocating. Otherwise the result
+// is finished in b, or built in a newly allocated buffer if b is nil.
+func (re *Regexp) ReplaceAll(s, template Str, b *Builder) Str {
+	return re.replace(s, b, func(dst []byte, m *Match) []byte {
+		return re.re.ExpandString(dst, template.String(), s.String(), m.loc)
+	})
+}
+
+// ReplaceAllFunc returns s with the matches of the regular expression replaced
+// by the return value of repl applied to them. The replacement is not expanded,
+// and must not be built in b. If there is no match, s is returned without
+// allocating. Otherwise the result is finished in b, or built in a newly
+// allocated buffer if b is nil.
+func (re *Regexp) ReplaceAllFunc(s Str, repl func(*Match) Str, b *Builder) Str {
+	return re.replace(s, b, func(dst []byte, m *Match) []byte {
+		return append(dst, repl(m).asBytes()...)
+	})
 }
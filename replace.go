@@ -0,0 +1,182 @@
+package str
+
+import (
+	"io"
+	"iter"
+	"unicode/utf8"
+
+	. "github.com/rprtr258/str/internal"
+)
+
+// Replacer replaces a list of strings with replacements.
+// The replaced text is produced as pieces which are either slices of the
+// input or the replacement strings themselves, so nothing is copied until
+// the result is written out or materialised.
+//
+// A Replacer is immutable and safe for concurrent use by multiple goroutines.
+type Replacer struct {
+	m   *Matcher
+	new []Str
+	// old holds the old strings, and empty the index of the first empty one,
+	// or -1. With an empty old string, the matcher is not used.
+	old   []Str
+	empty int
+}
+
+// NewReplacer returns a new [Replacer] from a list of old, new string
+// pairs. Replacements are performed in the order they appear in the
+// target string, without overlapping matches. The old string
+// comparisons are done in argument order.
+//
+// An empty old string matches at every position where no old string
+// before it matches, but not twice in a row at the same position. Unlike
+// [strings.NewReplacer], which also matches it between the bytes of UTF-8
+// sequences, it matches only at the beginning of the string and after each
+// UTF-8 sequence.
+//
+// NewReplacer panics if given an odd number of arguments.
+func NewReplacer(oldnew ...Str) *Replacer {
+	Assert(len(oldnew)%2 == 0, "str.NewReplacer: odd argument count")
+
+	old := make([]Str, 0, len(oldnew)/2)
+	new := make([]Str, 0, len(oldnew)/2)
+	empty := -1
+	for i := 0; i < len(oldnew); i += 2 {
+		if oldnew[i].Len == 0 && empty < 0 {
+			empty = len(old)
+		}
+		old = append(old, oldnew[i])
+		new = append(new, oldnew[i+1])
+	}
+	if empty >= 0 {
+		return &Replacer{new: new, old: old, empty: empty}
+	}
+	return &Replacer{m: NewMatcher(old, LeftmostFirst), new: new, old: old, empty: -1}
+}
+
+// Pieces returns an iterator over the pieces of s with all replacements performed.
+func (r *Replacer) Pieces(s Str) iter.Seq[Str] {
+	if r.empty < 0 {
+		return r.m.ReplaceAll(s, r.new)
+	}
+
+	return func(yield func(Str) bool) {
+		last, prevEmpty := 0, false
+		for i := 0; i <= s.Len; {
+			k := r.matchAt(s.SliceFrom(i), prevEmpty)
+			if k < 0 {
+				if i == s.Len {
+					break
+				}
+				_, wid := utf8.DecodeRuneInString(s.SliceFrom(i).String())
+				i += wid
+				prevEmpty = false
+				continue
+			}
+			if i > last && !yield(s.Slice(last, i)) {
+				return
+			}
+			if r.new[k].Len > 0 && !yield(r.new[k]) {
+				return
+			}
+			i += r.old[k].Len
+			last, prevEmpty = i, r.old[k].Len == 0
+		}
+		if last < s.Len {
+			yield(s.SliceFrom(last))
+		}
+	}
+}
+
+// matchAt returns the index of the first old string s starts with, or -1.
+// If skipEmpty is true, the empty old strings are skipped.
+func (r *Replacer) matchAt(s Str, skipEmpty bool) int {
+	for k, old := range r.old {
+		if old.Len == 0 {
+			if !skipEmpty {
+				return k
+			}
+		} else if HasPrefix(s, old) {
+			return k
+		}
+	}
+	return -1
+}
+
+// Replace returns a copy of s with all replacements performed.
+// If nothing is replaced, s itself is returned.
+func (r *Replacer) Replace(s Str) Str {
+	if r.m != nil && !r.m.Contains(s) {
+		return s
+	}
+	return Concat(r.Pieces(s))
+}
+
+// WriteString writes s to w with all replacements performed.
+func (r *Replacer) WriteString(w io.Writer, s Str) (n int, err error) {
+	m, err := WriteAll(w, r.Pieces(s))
+	return int(m), err
+}
+
+// ReplacePieces returns an iterator over the pieces of s with the first n
+// non-overlapping instances of old replaced by new.
+// If old is empty, it matches at the beginning of the string
+// and after each UTF-8 sequence, yielding up to k+1 replacements
+// for a k-rune string.
+// If n < 0, there is no limit on the number of replacements.
+func ReplacePieces(s, old, new Str, n int) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		start := 0
+		for i := 0; n < 0 || i < n; i++ {
+			j := start
+			if old.Len == 0 {
+				if i > 0 {
+					if start == s.Len {
+						break
+					}
+					_, wid := utf8.DecodeRuneInString(s.SliceFrom(start).String())
+					j += wid
+				}
+			} else {
+				k := Index(s.SliceFrom(start), old)
+				if k < 0 {
+					break
+				}
+				j += k
+			}
+			if j > start && !yield(s.Slice(start, j)) {
+				return
+			}
+			if new.Len > 0 && !yield(new) {
+				return
+			}
+			start = j + old.Len
+		}
+		if start < s.Len {
+			yield(s.SliceFrom(start))
+		}
+	}
+}
+
+// Replace returns a copy of the string s with the first n
+// non-overlapping instances of old replaced by new.
+// If old is empty, it matches at the beginning of the string
+// and after each UTF-8 sequence, yielding up to k+1 replacements
+// for a k-rune string.
+// If n < 0, there is no limit on the number of replacements.
+// If nothing is replaced, s itself is returned.
+func Replace(s, old, new Str, n int) Str {
+	if n == 0 || old.Len > 0 && Index(s, old) < 0 {
+		return s // avoid allocation
+	}
+	return Concat(ReplacePieces(s, old, new, n))
+}
+
+// ReplaceAll returns a copy of the string s with all
+// non-overlapping instances of old replaced by new.
+// If old is empty, it matches at the beginning of the string
+// and after each UTF-8 sequence, yielding up to k+1 replacements
+// for a k-rune string.
+func ReplaceAll(s, old, new Str) Str {
+	return Replace(s, old, new, -1)
+}
@@ -0,0 +1,63 @@
+package str
+
+import (
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestConcatSingleUse(t *testing.T) {
+	used := false
+	pieces := func(yield func(Str) bool) {
+		if used {
+			t.Fatal("pieces iterated twice")
+		}
+		used = true
+		for _, p := range []string{"a", "", "bc", "d"} {
+			if !yield(NewFromString(p)) {
+				return
+			}
+		}
+	}
+	next, stop := iter.Pull(iter.Seq[Str](pieces))
+	defer stop()
+	pulled := func(yield func(Str) bool) {
+		for p, ok := next(); ok; p, ok = next() {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+	if got := Concat(pulled).String(); got != "abcd" {
+		t.Errorf("Concat = %q, want %q", got, "abcd")
+	}
+}
+
+func TestReplacerEmptyOld(t *testing.T) {
+	for _, tc := range []struct {
+		oldnew []string
+		s      string
+	}{
+		{[]string{"", "X"}, "ab"},
+		{[]string{"", "X"}, ""},
+		{[]string{"a", "1", "", "X"}, "ab"},
+		{[]string{"", "X", "a", "1"}, "ab"},
+		{[]string{"", "X", "", "Y"}, "ab"},
+		{[]string{"ab", "", "", "-", "b", "B"}, "abbab"},
+	} {
+		var oldnew []Str
+		for _, s := range tc.oldnew {
+			oldnew = append(oldnew, NewFromString(s))
+		}
+		want := strings.NewReplacer(tc.oldnew...).Replace(tc.s)
+		if got := NewReplacer(oldnew...).Replace(NewFromString(tc.s)).String(); got != want {
+			t.Errorf("NewReplacer(%q).Replace(%q) = %q, want %q", tc.oldnew, tc.s, got, want)
+		}
+	}
+
+	// Unlike strings.Replacer, UTF-8 sequences are not split.
+	r := NewReplacer(NewFromString(""), NewFromString("|"))
+	if got := r.Replace(NewFromString("aé")).String(); got != "|a|é|" {
+		t.Errorf("Replace = %q, want %q", got, "|a|é|")
+	}
+}
@@ -0,0 +1,126 @@
+package str
+
+import (
+	"slices"
+	"sync"
+	"unicode/utf8"
+
+	. "github.com/rprtr258/str/internal"
+)
+
+// runesIndexStride is the number of runes between two entries
+// of the sparse index of Runes.
+const runesIndexStride = 64
+
+// Runes is a view of a Str indexed by Unicode code points rather than bytes.
+// Each byte of an invalid UTF-8 sequence counts as one code point,
+// utf8.RuneError, as in a range loop over a string.
+//
+// Positions in strings other than ASCII are found through a sparse index of
+// byte offsets, built on first use, which makes rune-indexed accesses take
+// constant time and byte-indexed ones logarithmic time.
+//
+// A Runes is safe for concurrent use by multiple goroutines.
+type Runes struct {
+	s     Str
+	count int
+
+	once sync.Once
+	// index holds the byte offset of every runesIndexStride-th rune.
+	index []int
+}
+
+// NewRunes returns a new Runes view of s.
+func NewRunes(s Str) *Runes {
+	return &Runes{s: s, count: utf8.RuneCount(s.asBytes())}
+}
+
+// Str returns the viewed string.
+func (r *Runes) Str() Str {
+	return r.s
+}
+
+// RuneCount returns the number of runes in the viewed string.
+func (r *Runes) RuneCount() int {
+	return r.count
+}
+
+// isASCII reports whether every rune of the viewed string is a single byte,
+// so that rune and byte offsets coincide.
+func (r *Runes) isASCII() bool {
+	return r.count == r.s.Len
+}
+
+func (r *Runes) buildIndex() {
+	r.once.Do(func() {
+		r.index = make([]int, 0, r.count/runesIndexStride+1)
+		i := 0
+		for off := range r.s.String() {
+			if i%runesIndexStride == 0 {
+				r.index = append(r.index, off)
+			}
+			i++
+		}
+	})
+}
+
+// ByteOffset returns the byte offset of the rune with index i.
+// i may be RuneCount, which gives the length of the viewed string.
+func (r *Runes) ByteOffset(i int) int {
+	Assert(0 <= i && i <= r.count, "rune index out of range")
+
+	if r.isASCII() || i == r.count {
+		return r.s.Len - (r.count - i)
+	}
+
+	r.buildIndex()
+	str := r.s.String()
+	off := r.index[i/runesIndexStride]
+	for range i % runesIndexStride {
+		_, n := decodeRune(str[off:])
+		off += n
+	}
+	return off
+}
+
+// RuneOffset returns the index of the rune containing the byte at offset off.
+// off may be the length of the viewed string, which gives RuneCount.
+func (r *Runes) RuneOffset(off int) int {
+	Assert(0 <= off && off <= r.s.Len, "byte offset out of range")
+
+	if r.isASCII() || off == r.s.Len {
+		return r.count - (r.s.Len - off)
+	}
+
+	r.buildIndex()
+	str := r.s.String()
+	j, found := slices.BinarySearch(r.index, off)
+	if !found {
+		j--
+	}
+	i, start := j*runesIndexStride, r.index[j]
+	for {
+		_, n := decodeRune(str[start:])
+		if start+n > off {
+			return i
+		}
+		start += n
+		i++
+	}
+}
+
+// RuneAt returns the rune with index i.
+func (r *Runes) RuneAt(i int) rune {
+	Assert(0 <= i && i < r.count, "rune index out of range")
+
+	c, _ := decodeRune(r.s.SliceFrom(r.ByteOffset(i)).String())
+	return c
+}
+
+// SliceRunes returns the part of the viewed string from the rune with index
+// from up to, but not including, the rune with index to.
+func (r *Runes) SliceRunes(from, to int) Str {
+	Assert(from <= to, "invalid rune slice indices")
+
+	return r.s.Slice(r.ByteOffset(from), r.ByteOffset(to))
+}
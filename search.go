@@ -0,0 +1,242 @@
+package str
+
+import "bytes"
+
+// primeRK is the prime base used in Rabin-Karp algorithm.
+const primeRK = 16777619
+
+// maxShortNeedle is the length of the longest needle searched with Rabin-Karp
+// once the byte scan gives up. Longer needles are searched with
+// Boyer-Moore-Horspool, which skips over most of the haystack.
+const maxShortNeedle = 32
+
+// rabinKarp holds the needle hash and the multiplicative factor
+// for use in Rabin-Karp algorithm.
+type rabinKarp struct {
+	hash, pow uint32
+}
+
+// makeRabinKarp computes the Rabin-Karp state of sep.
+func makeRabinKarp(sep Str) rabinKarp {
+	hash := uint32(0)
+	for _, c := range sep.asBytes() {
+		hash = hash*primeRK + uint32(c)
+	}
+	return rabinKarp{hash: hash, pow: powRK(sep.Len)}
+}
+
+// powRK returns primeRK**n.
+func powRK(n int) uint32 {
+	var pow, sq uint32 = 1, primeRK
+	for i := n; i > 0; i >>= 1 {
+		if i&1 != 0 {
+			pow *= sq
+		}
+		sq *= sq
+	}
+	return pow
+}
+
+// makeRabinKarpRev computes the Rabin-Karp state of sep
+// for searching from the end of the string.
+func makeRabinKarpRev(sep Str) rabinKarp {
+	hash := uint32(0)
+	b := sep.asBytes()
+	for i := len(b) - 1; i >= 0; i-- {
+		hash = hash*primeRK + uint32(b[i])
+	}
+	return rabinKarp{hash: hash, pow: powRK(sep.Len)}
+}
+
+// index uses the Rabin-Karp search algorithm to return the index of the
+// first occurrence of sep in s, or -1 if not present.
+func (rk rabinKarp) index(s, sep Str) int {
+	n := sep.Len
+	if s.Len < n {
+		return -1
+	}
+
+	b, p := s.asBytes(), sep.String()
+	var h uint32
+	for i := range n {
+		h = h*primeRK + uint32(b[i])
+	}
+	if h == rk.hash && string(b[:n]) == p {
+		return 0
+	}
+	for i := n; i < len(b); {
+		h *= primeRK
+		h += uint32(b[i])
+		h -= rk.pow * uint32(b[i-n])
+		i++
+		if h == rk.hash && string(b[i-n:i]) == p {
+			return i - n
+		}
+	}
+	return -1
+}
+
+// lastIndex uses the Rabin-Karp search algorithm to return the index of the
+// last occurrence of sep in s, or -1 if not present.
+// rk must be built by makeRabinKarpRev.
+func (rk rabinKarp) lastIndex(s, sep Str) int {
+	n := sep.Len
+	if s.Len < n {
+		return -1
+	}
+
+	b, p := s.asBytes(), sep.String()
+	last := len(b) - n
+	var h uint32
+	for i := len(b) - 1; i >= last; i-- {
+		h = h*primeRK + uint32(b[i])
+	}
+	if h == rk.hash && string(b[last:]) == p {
+		return last
+	}
+	for i := last - 1; i >= 0; i-- {
+		h *= primeRK
+		h += uint32(b[i])
+		h -= rk.pow * uint32(b[i+n])
+		if h == rk.hash && string(b[i:i+n]) == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// scanIndex looks for the first occurrence of sep in s by scanning for the
+// first byte of sep, which is fast as long as that byte is rare in s.
+// sep must be at least 2 bytes long.
+//
+// If done is true, i is the result of the search. Otherwise the scan produced
+// too many false positives and gave up: no occurrence starts before i, and the
+// rest of s must be searched with one of the skipping algorithms.
+func scanIndex(s, sep Str) (i int, done bool) {
+	n := sep.Len
+	b, p := s.asBytes(), sep.String()
+	c0, c1 := p[0], p[1]
+	t := len(b) - n + 1
+	fails := 0
+	for i < t {
+		if b[i] != c0 {
+			o := bytes.IndexByte(b[i+1:t], c0)
+			if o < 0 {
+				return -1, true
+			}
+			i += o + 1
+		}
+		if b[i+1] == c1 && string(b[i:i+n]) == p {
+			return i, true
+		}
+		i++
+		fails++
+		if fails > 4+i>>4 && i < t {
+			return i, false
+		}
+	}
+	return -1, true
+}
+
+// scanLastIndex is the reverse of scanIndex: it looks for the last occurrence
+// of sep in s by scanning backwards for the last byte of sep.
+// sep must be at least 2 bytes long.
+//
+// If done is true, j is the result of the search. Otherwise no occurrence
+// ends after j, and s[:j] must be searched with one of the skipping algorithms.
+func scanLastIndex(s, sep Str) (j int, done bool) {
+	n := sep.Len
+	b, p := s.asBytes(), sep.String()
+	c0, c1 := p[n-1], p[n-2]
+	fails := 0
+	for j = len(b); j >= n; {
+		if b[j-1] != c0 {
+			o := bytes.LastIndexByte(b[n-1:j-1], c0)
+			if o < 0 {
+				return -1, true
+			}
+			j = n + o
+		}
+		if b[j-2] == c1 && string(b[j-n:j]) == p {
+			return j - n, true
+		}
+		j--
+		fails++
+		if fails > 4+(len(b)-j)>>4 && j >= n {
+			return j, false
+		}
+	}
+	return -1, true
+}
+
+// offset converts j, the result of a search in s[i:], into an index in s.
+func offset(i, j int) int {
+	if j < 0 {
+		return -1
+	}
+	return i + j
+}
+
+// horspool is the bad character shift table of the Boyer-Moore-Horspool
+// algorithm: for each byte, how far the search window may advance when that
+// byte is under the last position of the window.
+type horspool [256]int
+
+// makeHorspool builds the shift table of sep.
+func makeHorspool(sep Str) (h horspool) {
+	n := sep.Len
+	for i := range h {
+		h[i] = n
+	}
+	for i, c := range sep.asBytes()[:n-1] {
+		h[c] = n - 1 - i
+	}
+	return h
+}
+
+// index uses the Boyer-Moore-Horspool search algorithm to return the index of
+// the first occurrence of sep in s, or -1 if not present. h must be built from sep.
+func (h *horspool) index(s, sep Str) int {
+	n := sep.Len
+	b, p := s.asBytes(), sep.String()
+	last := p[n-1]
+	for i := n - 1; i < len(b); {
+		c := b[i]
+		if c == last && string(b[i-n+1:i+1]) == p {
+			return i - n + 1
+		}
+		i += h[c]
+	}
+	return -1
+}
+
+// makeHorspoolRev builds the shift table of sep for searching from the end of
+// the string, where the window moves left and is keyed by its first byte.
+func makeHorspoolRev(sep Str) (h horspool) {
+	n := sep.Len
+	for i := range h {
+		h[i] = n
+	}
+	b := sep.asBytes()
+	for i := n - 1; i > 0; i-- {
+		h[b[i]] = i
+	}
+	return h
+}
+
+// lastIndex uses the Boyer-Moore-Horspool search algorithm to return the index
+// of the last occurrence of sep in s, or -1 if not present.
+// h must be built by makeHorspoolRev from sep.
+func (h *horspool) lastIndex(s, sep Str) int {
+	n := sep.Len
+	b, p := s.asBytes(), sep.String()
+	first := p[0]
+	for i := len(b) - n; i >= 0; {
+		c := b[i]
+		if c == first && string(b[i:i+n]) == p {
+			return i
+		}
+		i -= h[c]
+	}
+	return -1
+}
@@ -0,0 +1,396 @@
+package str
+
+import (
+	"iter"
+	"unicode/utf8"
+)
+
+//go:generate go run maketables.go
+
+// propRange assigns the property value prop to the code points lo through hi.
+type propRange struct {
+	lo, hi rune
+	prop   uint8
+}
+
+// lookupProp returns the property value of r in table,
+// or 0 if r is not listed in table.
+func lookupProp(table []propRange, r rune) uint8 {
+	i, j := 0, len(table)
+	for i < j {
+		h := int(uint(i+j) >> 1) // avoid overflow when computing h
+		switch {
+		case r < table[h].lo:
+			j = h
+		case r > table[h].hi:
+			i = h + 1
+		default:
+			return table[h].prop
+		}
+	}
+	return 0
+}
+
+// extPict flags the code points with the Extended_Pictographic property
+// in graphemeBreakTable and wordBreakTable.
+const extPict = 0x80
+
+// Grapheme_Cluster_Break property values.
+const (
+	gbOther uint8 = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbPrepend
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+)
+
+// Word_Break property values.
+const (
+	wbOther uint8 = iota
+	wbDoubleQuote
+	wbSingleQuote
+	wbHebrewLetter
+	wbCR
+	wbLF
+	wbNewline
+	wbExtend
+	wbRegionalIndicator
+	wbFormat
+	wbKatakana
+	wbALetter
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet
+	wbNumeric
+	wbExtendNumLet
+	wbZWJ
+	wbWSegSpace
+)
+
+// Sentence_Break property values.
+const (
+	sbOther uint8 = iota
+	sbCR
+	sbLF
+	sbExtend
+	sbSep
+	sbFormat
+	sbSp
+	sbLower
+	sbUpper
+	sbOLetter
+	sbNumeric
+	sbATerm
+	sbSContinue
+	sbSTerm
+	sbClose
+)
+
+// segments returns an iterator over the pieces of s delimited by next,
+// which returns the length of the piece at the start of a non-empty string.
+func segments(s Str, next func(string) int) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		str := s.String()
+		for i := 0; i < len(str); {
+			n := next(str[i:])
+			if !yield(s.Slice(i, i+n)) {
+				return
+			}
+			i += n
+		}
+	}
+}
+
+// Graphemes returns an iterator over the extended grapheme clusters of s,
+// as defined by Unicode Standard Annex #29: the user-perceived characters,
+// such as a letter with its combining marks, a Hangul syllable or an emoji
+// sequence. The clusters are yielded as slices of s.
+func Graphemes(s Str) iter.Seq[Str] {
+	return segments(s, graphemeLen)
+}
+
+// Words returns an iterator over the pieces of s between word boundaries,
+// as defined by Unicode Standard Annex #29. Words as well as the runs of
+// spaces and punctuation between them are yielded, as slices of s.
+func Words(s Str) iter.Seq[Str] {
+	return segments(s, wordLen)
+}
+
+// Sentences returns an iterator over the sentences of s, as defined by
+// Unicode Standard Annex #29. Each sentence is yielded as a slice of s,
+// including its trailing spaces and paragraph separator.
+func Sentences(s Str) iter.Seq[Str] {
+	return segments(s, sentenceLen)
+}
+
+// graphemeLen returns the length of the extended grapheme cluster
+// at the start of the non-empty string s.
+func graphemeLen(s string) int {
+	if s[0] < utf8.RuneSelf && s[0] != '\r' && (len(s) == 1 || s[1] < utf8.RuneSelf) {
+		// ASCII fast path: ASCII characters other than CR LF
+		// do not combine with the following ASCII character.
+		return 1
+	}
+
+	r, i := decodeRune(s)
+	prev := lookupProp(graphemeBreakTable, r)
+	// riCount is the number of regional indicators ending at prev.
+	riCount := 0
+	// emoji reports whether prev ends an Extended_Pictographic Extend* sequence,
+	// and zwj whether prev is a ZWJ following one.
+	emoji, zwj := prev&extPict != 0, false
+	if prev == gbRegionalIndicator {
+		riCount = 1
+	}
+
+	for i < len(s) {
+		r, n := decodeRune(s[i:])
+		next := lookupProp(graphemeBreakTable, r)
+		gb, pict := next&^extPict, next&extPict != 0
+		if graphemeBreak(prev&^extPict, gb, pict, zwj, riCount) {
+			break
+		}
+
+		switch {
+		case pict:
+			emoji, zwj = true, false
+		case gb == gbExtend:
+			zwj = false
+		case gb == gbZWJ:
+			emoji, zwj = false, emoji
+		default:
+			emoji, zwj = false, false
+		}
+		if gb == gbRegionalIndicator {
+			riCount++
+		} else {
+			riCount = 0
+		}
+		prev = next
+		i += n
+	}
+	return i
+}
+
+// graphemeBreak reports whether there is a grapheme cluster boundary between
+// characters with the properties prev and next. pict reports whether next is
+// Extended_Pictographic, zwj whether prev is a ZWJ ending an emoji sequence,
+// and riCount is the number of regional indicators ending at prev.
+func graphemeBreak(prev, next uint8, pict, zwj bool, riCount int) bool {
+	switch {
+	case prev == gbCR && next == gbLF: // GB3
+		return false
+	case prev == gbCR, prev == gbLF, prev == gbControl: // GB4
+		return true
+	case next == gbCR, next == gbLF, next == gbControl: // GB5
+		return true
+	case prev == gbL && (next == gbL || next == gbV || next == gbLV || next == gbLVT): // GB6
+		return false
+	case (prev == gbLV || prev == gbV) && (next == gbV || next == gbT): // GB7
+		return false
+	case (prev == gbLVT || prev == gbT) && next == gbT: // GB8
+		return false
+	case next == gbExtend, next == gbZWJ: // GB9
+		return false
+	case next == gbSpacingMark: // GB9a
+		return false
+	case prev == gbPrepend: // GB9b
+		return false
+	case zwj && pict: // GB11
+		return false
+	case prev == gbRegionalIndicator && next == gbRegionalIndicator: // GB12, GB13
+		return riCount%2 == 0
+	default: // GB999
+		return true
+	}
+}
+
+func isAHLetter(p uint8) bool { return p == wbALetter || p == wbHebrewLetter }
+
+func isMidNumLetQ(p uint8) bool { return p == wbMidNumLet || p == wbSingleQuote }
+
+func isWordIgnorable(p uint8) bool { return p == wbExtend || p == wbFormat || p == wbZWJ }
+
+func isNewline(p uint8) bool { return p == wbNewline || p == wbCR || p == wbLF }
+
+// wordLen returns the length of the piece of the non-empty string s
+// up to the first word boundary.
+func wordLen(s string) int {
+	r, i := decodeRune(s)
+	raw := lookupProp(wordBreakTable, r)
+	// prev and prevPrev are the last two characters, ignoring
+	// Extend, Format and ZWJ characters as rule WB4 requires.
+	prev, prevPrev := raw&^extPict, wbOther
+	riCount := 0
+	if prev == wbRegionalIndicator {
+		riCount = 1
+	}
+
+	for i < len(s) {
+		r, n := decodeRune(s[i:])
+		next := lookupProp(wordBreakTable, r)
+		p := next &^ extPict
+		switch rawPrev := raw &^ extPict; {
+		case rawPrev == wbCR && p == wbLF: // WB3
+		case isNewline(rawPrev) || isNewline(p): // WB3a, WB3b
+			return i
+		case rawPrev == wbZWJ && next&extPict != 0: // WB3c
+		case rawPrev == wbWSegSpace && p == wbWSegSpace: // WB3d
+		case isWordIgnorable(p): // WB4
+			raw = next
+			i += n
+			continue
+		default:
+			if wordBreak(prevPrev, prev, p, riCount, s[i+n:]) {
+				return i
+			}
+		}
+
+		raw = next
+		prev, prevPrev = p, prev
+		if p == wbRegionalIndicator {
+			riCount++
+		} else {
+			riCount = 0
+		}
+		i += n
+	}
+	return i
+}
+
+// wordBreak reports whether there is a word boundary between characters
+// with the properties prev and next, by rules WB5 to WB999. prevPrev is
+// the property of the character before prev, riCount the number of regional
+// indicators ending at prev and rest the text after next.
+func wordBreak(prevPrev, prev, next uint8, riCount int, rest string) bool {
+	switch {
+	case isAHLetter(prev) && isAHLetter(next): // WB5
+		return false
+	case isAHLetter(prev) && (next == wbMidLetter || isMidNumLetQ(next)) && isAHLetter(peekWord(rest)): // WB6
+		return false
+	case isAHLetter(prevPrev) && (prev == wbMidLetter || isMidNumLetQ(prev)) && isAHLetter(next): // WB7
+		return false
+	case prev == wbHebrewLetter && next == wbSingleQuote: // WB7a
+		return false
+	case prev == wbHebrewLetter && next == wbDoubleQuote && peekWord(rest) == wbHebrewLetter: // WB7b
+		return false
+	case prevPrev == wbHebrewLetter && prev == wbDoubleQuote && next == wbHebrewLetter: // WB7c
+		return false
+	case prev == wbNumeric && next == wbNumeric: // WB8
+		return false
+	case isAHLetter(prev) && next == wbNumeric: // WB9
+		return false
+	case prev == wbNumeric && isAHLetter(next): // WB10
+		return false
+	case prevPrev == wbNumeric && (prev == wbMidNum || isMidNumLetQ(prev)) && next == wbNumeric: // WB11
+		return false
+	case prev == wbNumeric && (next == wbMidNum || isMidNumLetQ(next)) && peekWord(rest) == wbNumeric: // WB12
+		return false
+	case prev == wbKatakana && next == wbKatakana: // WB13
+		return false
+	case (isAHLetter(prev) || prev == wbNumeric || prev == wbKatakana || prev == wbExtendNumLet) && next == wbExtendNumLet: // WB13a
+		return false
+	case prev == wbExtendNumLet && (isAHLetter(next) || next == wbNumeric || next == wbKatakana): // WB13b
+		return false
+	case prev == wbRegionalIndicator && next == wbRegionalIndicator: // WB15, WB16
+		return riCount%2 == 0
+	default: // WB999
+		return true
+	}
+}
+
+// peekWord returns the Word_Break property of the first character of s
+// which is not ignored by rule WB4, or wbOther if there is none.
+func peekWord(s string) uint8 {
+	for i := 0; i < len(s); {
+		r, n := decodeRune(s[i:])
+		if p := lookupProp(wordBreakTable, r) &^ extPict; !isWordIgnorable(p) {
+			return p
+		}
+		i += n
+	}
+	return wbOther
+}
+
+func isParaSep(p uint8) bool { return p == sbSep || p == sbCR || p == sbLF }
+
+func isSATerm(p uint8) bool { return p == sbSTerm || p == sbATerm }
+
+// sentenceLen returns the length of the sentence
+// at the start of the non-empty string s.
+func sentenceLen(s string) int {
+	r, i := decodeRune(s)
+	raw := lookupProp(sentenceBreakTable, r)
+	// prev and prevPrev are the last two characters, ignoring
+	// Extend and Format characters as rule SB5 requires.
+	prev, prevPrev := raw, sbOther
+	// term is the SATerm of the SATerm Close* Sp* sequence ending at prev,
+	// or sbOther, and sp reports whether the sequence has reached Sp*.
+	term, sp := sbOther, false
+	if isSATerm(prev) {
+		term = prev
+	}
+
+	for i < len(s) {
+		r, n := decodeRune(s[i:])
+		next := lookupProp(sentenceBreakTable, r)
+		switch {
+		case raw == sbCR && next == sbLF: // SB3
+		case isParaSep(raw): // SB4
+			return i
+		case next == sbExtend || next == sbFormat: // SB5
+			raw = next
+			i += n
+			continue
+		case prev == sbATerm && next == sbNumeric: // SB6
+		case (prevPrev == sbUpper || prevPrev == sbLower) && prev == sbATerm && next == sbUpper: // SB7
+		case term == sbATerm && peekSentenceLower(s[i:]): // SB8
+		case term != sbOther && (next == sbSContinue || isSATerm(next)): // SB8a
+		case term != sbOther && !sp && (next == sbClose || next == sbSp || isParaSep(next)): // SB9
+		case term != sbOther && (next == sbSp || isParaSep(next)): // SB10
+		case term != sbOther: // SB11
+			return i
+		default: // SB998
+		}
+
+		switch {
+		case isSATerm(next):
+			term, sp = next, false
+		case next == sbClose && term != sbOther && !sp:
+		case next == sbSp && term != sbOther:
+			sp = true
+		default:
+			term, sp = sbOther, false
+		}
+		raw = next
+		prev, prevPrev = next, prev
+		i += n
+	}
+	return i
+}
+
+// peekSentenceLower reports whether the first character of s with a
+// Sentence_Break property of OLetter, Upper, Lower, ParaSep or SATerm
+// is Lower, as rule SB8 requires.
+func peekSentenceLower(s string) bool {
+	for i := 0; i < len(s); {
+		r, n := decodeRune(s[i:])
+		switch p := lookupProp(sentenceBreakTable, r); {
+		case p == sbLower:
+			return true
+		case p == sbOLetter, p == sbUpper, isParaSep(p), isSATerm(p):
+			return false
+		}
+		i += n
+	}
+	return false
+}
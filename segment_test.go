@@ -0,0 +1,73 @@
+package str
+
+import (
+	"bufio"
+	"iter"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testBreaks runs the cases of a break test file of the Unicode Character
+// Database in testdata, of the Unicode version of the tables, against split.
+func testBreaks(t *testing.T, name string, split func(Str) iter.Seq[Str]) {
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		var s []byte
+		var want []string
+		start := 0
+		for _, field := range strings.Fields(text) {
+			switch field {
+			case "÷":
+				if len(s) > start {
+					want = append(want, string(s[start:]))
+				}
+				start = len(s)
+			case "×":
+			default:
+				r, err := strconv.ParseUint(field, 16, 32)
+				if err != nil {
+					t.Fatalf("%s:%d: %v", name, line, err)
+				}
+				s = utf8.AppendRune(s, rune(r))
+			}
+		}
+
+		var got []string
+		for piece := range split(NewFromBytes(s)) {
+			got = append(got, piece.String())
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s:%d: %q: got %q, want %q", name, line, s, got, want)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	testBreaks(t, "GraphemeBreakTest.txt", Graphemes)
+}
+
+func TestWords(t *testing.T) {
+	testBreaks(t, "WordBreakTest.txt", Words)
+}
+
+func TestSentences(t *testing.T) {
+	testBreaks(t, "SentenceBreakTest.txt", Sentences)
+}
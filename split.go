@@ -8,28 +8,31 @@ import (
 // explode splits s into a slice of UTF-8 strings,
 // one string per Unicode character up to a maximum of n (n < 0 means no limit).
 // Invalid UTF-8 bytes are sliced individually.
-func explode(s Str, n int) iter.Seq[Str] {
+func explode(s Str, n int) iter.Seq2[int, Str] {
 	l := utf8.RuneCount(s.asBytes())
 	if n < 0 || n > l {
 		n = l
 	}
-	return func(yield func(Str) bool) {
+	return func(yield func(int, Str) bool) {
+		str, i := s.String(), 0
 		for range n - 1 {
-			_, size := utf8.DecodeRuneInString(s.String())
-			if !yield(s.SliceTo(size)) {
+			_, size := utf8.DecodeRuneInString(str[i:])
+			if !yield(i, s.Slice(i, i+size)) {
 				return
 			}
-			s = s.SliceFrom(size)
+			i += size
 		}
 		if n > 0 {
-			yield(s)
+			yield(i, s.SliceFrom(i))
 		}
 	}
 }
 
 // Generic split: splits after each instance of sep,
-// including sepSave bytes of sep in the subarrays.
-func genSplit(s, sep Str, sepSave, n int) iter.Seq[Str] {
+// including sepSave bytes of sep in the subarrays,
+// and yields the subarrays with their offsets in s.
+// Instances of sep are located with index.
+func genSplit(s, sep Str, sepSave, n int, index func(Str) int) iter.Seq2[int, Str] {
 	if n == 0 {
 		return nil
 	}
@@ -38,27 +41,27 @@ func genSplit(s, sep Str, sepSave, n int) iter.Seq[Str] {
 		return explode(s, n)
 	}
 
-	if n < 0 {
-		n = Count(s, sep) + 1
-	}
-
-	return func(yield func(Str) bool) {
-		n = min(n, s.Len+1)
-		n--
-		for range n {
-			m := Index(s, sep)
+	return func(yield func(int, Str) bool) {
+		i := 0
+		for k := 1; n < 0 || k < n; k++ {
+			m := index(s.SliceFrom(i))
 			if m < 0 {
 				break
 			}
-			if !yield(s.SliceTo(m + sepSave)) {
+			if !yield(i, s.Slice(i, i+m+sepSave)) {
 				return
 			}
-			s = s.SliceFrom(m + sep.Len)
+			i += m + sep.Len
 		}
-		yield(s)
+		yield(i, s.SliceFrom(i))
 	}
 }
 
+// indexOf returns a function locating sep with Index.
+func indexOf(sep Str) func(Str) int {
+	return func(s Str) int { return Index(s, sep) }
+}
+
 // SplitN slices s into substrings separated by sep and returns a slice of
 // the substrings between those separators.
 //
@@ -72,7 +75,7 @@ func genSplit(s, sep Str, sepSave, n int) iter.Seq[Str] {
 // as described in the documentation for [Split].
 //
 // To split around the first instance of a separator, see Cut.
-func SplitN(s, sep Str, n int) iter.Seq[Str] { return genSplit(s, sep, 0, n) }
+func SplitN(s, sep Str, n int) iter.Seq[Str] { return values(genSplit(s, sep, 0, n, indexOf(sep))) }
 
 // SplitAfterN slices s into substrings after each instance of sep and
 // returns a slice of those substrings.
@@ -85,7 +88,9 @@ func SplitN(s, sep Str, n int) iter.Seq[Str] { return genSplit(s, sep, 0, n) }
 //
 // Edge cases for s and sep (for example, empty strings) are handled
 // as described in the documentation for SplitAfter.
-func SplitAfterN(s, sep Str, n int) iter.Seq[Str] { return genSplit(s, sep, sep.Len, n) }
+func SplitAfterN(s, sep Str, n int) iter.Seq[Str] {
+	return values(genSplit(s, sep, sep.Len, n, indexOf(sep)))
+}
 
 // Split slices s into all substrings separated by sep and returns a slice of
 // the substrings between those separators.
@@ -99,7 +104,7 @@ func SplitAfterN(s, sep Str, n int) iter.Seq[Str] { return genSplit(s, sep, sep.
 // It is equivalent to [SplitN] with a count of -1.
 //
 // To split around the first instance of a separator, see Cut.
-func Split(s, sep Str) iter.Seq[Str] { return genSplit(s, sep, 0, -1) }
+func Split(s, sep Str) iter.Seq[Str] { return values(genSplit(s, sep, 0, -1, indexOf(sep))) }
 
 // SplitAfter slices s into all substrings after each instance of sep and
 // returns a slice of those substrings.
@@ -111,4 +116,13 @@ func Split(s, sep Str) iter.Seq[Str] { return genSplit(s, sep, 0, -1) }
 // both s and sep are empty, SplitAfter returns an empty slice.
 //
 // It is equivalent to [SplitAfterN] with a count of -1.
-func SplitAfter(s, sep Str) iter.Seq[Str] { return genSplit(s, sep, sep.Len, -1) }
+func SplitAfter(s, sep Str) iter.Seq[Str] { return values(genSplit(s, sep, sep.Len, -1, indexOf(sep))) }
+
+// SplitIndexed is like [Split], but also yields the offset in s of each substring,
+// which is not recoverable from the substring itself when it is empty.
+func SplitIndexed(s, sep Str) iter.Seq2[int, Str] { return genSplit(s, sep, 0, -1, indexOf(sep)) }
+
+// SplitAfterIndexed is like [SplitAfter], but also yields the offset in s of each substring.
+func SplitAfterIndexed(s, sep Str) iter.Seq2[int, Str] {
+	return genSplit(s, sep, sep.Len, -1, indexOf(sep))
+}
@@ -22,7 +22,7 @@ func NewFromString(s string) Str {
 
 func NewFromSubstring(s string, start, n int) Str {
 	Assert(n >= 0, "invalid substring length")
-	Assert(start < 0 || start >= len(s), "invalid substring start")
+	Assert(0 <= start && start <= len(s), "invalid substring start")
 
 	return Str(view.View[byte](NewFromString(s)).Slice(start, start+n))
 }
@@ -54,3 +54,10 @@ func (s Str) All() iter.Seq[byte] {
 func (s Str) Get(i int) byte {
 	return view.View[byte](s).Get(i)
 }
+
+// OffsetIn reports whether s is a view of the memory of parent, and if so
+// returns the offset of s in parent, which is parent.Len for empty strings
+// sliced at the end of parent.
+func (s Str) OffsetIn(parent Str) (int, bool) {
+	return view.View[byte](s).OffsetIn(view.View[byte](parent))
+}
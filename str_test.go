@@ -0,0 +1,31 @@
+package str
+
+import "testing"
+
+func TestOffsetIn(t *testing.T) {
+	parent := NewFromString("hello, world")
+	for _, tc := range []struct {
+		from, to int
+	}{
+		{0, 0}, {0, 5}, {7, 12}, {5, 5}, {11, 11}, {12, 12},
+	} {
+		s := parent.Slice(tc.from, tc.to)
+		if off, ok := s.OffsetIn(parent); !ok || off != tc.from {
+			t.Errorf("Slice(%d, %d).OffsetIn = %d, %v, want %d, true", tc.from, tc.to, off, ok, tc.from)
+		}
+	}
+
+	tail := parent.SliceFrom(parent.Len)
+	if off, ok := tail.OffsetIn(parent.SliceFrom(7)); !ok || off != 5 {
+		t.Errorf("tail.OffsetIn(suffix) = %d, %v, want 5, true", off, ok)
+	}
+	if off, ok := tail.SliceFrom(0).OffsetIn(parent); !ok || off != parent.Len {
+		t.Errorf("tail.SliceFrom(0).OffsetIn = %d, %v, want %d, true", off, ok, parent.Len)
+	}
+	if _, ok := tail.OffsetIn(parent.SliceTo(5)); ok {
+		t.Error("tail.OffsetIn(prefix) = true, want false")
+	}
+	if _, ok := NewFromString("other").OffsetIn(parent); ok {
+		t.Error("OffsetIn(unrelated) = true, want false")
+	}
+}
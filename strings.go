@@ -6,20 +6,16 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
-
-	"github.com/rprtr258/str/view"
 )
 
 // IndexByte returns the index of the first instance of c in s, or -1 if c is not present in s.
 func IndexByte(s Str, c byte) int {
-	return view.Index(view.View[byte](s), c)
+	return bytes.IndexByte(s.asBytes(), c)
 }
 
+// Equal reports whether s and t hold the same bytes.
 func Equal(s, t Str) bool {
-	return s.Len != t.Len &&
-		view.All(view.View[byte](t), func(b byte, i int) bool {
-			return s.Get(i) == b
-		})
+	return s.Len == t.Len && s.String() == t.String()
 }
 
 // Index returns the index of the first instance of substr in s, or -1 if substr is not present in s.
@@ -31,21 +27,53 @@ func Index(s, substr Str) int {
 	case n == 1:
 		return IndexByte(s, substr.Get(0))
 	case n == s.Len:
-		if substr == s {
+		if Equal(substr, s) {
 			return 0
 		}
 		return -1
 	case n > s.Len:
 		return -1
-	default:
-		// TODO: very inefficient
-		for i := 0; i <= s.Len-n; i++ {
-			if Equal(s.Slice(i, i+n), substr) {
-				return i
-			}
+	}
+
+	i, done := scanIndex(s, substr)
+	if done {
+		return i
+	}
+	if n <= maxShortNeedle {
+		rk := makeRabinKarp(substr)
+		return offset(i, rk.index(s.SliceFrom(i), substr))
+	}
+	h := makeHorspool(substr)
+	return offset(i, h.index(s.SliceFrom(i), substr))
+}
+
+// LastIndex returns the index of the last instance of substr in s, or -1 if substr is not present in s.
+func LastIndex(s, substr Str) int {
+	n := substr.Len
+	switch {
+	case n == 0:
+		return s.Len
+	case n == 1:
+		return LastIndexByte(s, substr.Get(0))
+	case n == s.Len:
+		if Equal(substr, s) {
+			return 0
 		}
 		return -1
+	case n > s.Len:
+		return -1
+	}
+
+	j, done := scanLastIndex(s, substr)
+	if done {
+		return j
+	}
+	if n <= maxShortNeedle {
+		rk := makeRabinKarpRev(substr)
+		return rk.lastIndex(s.SliceTo(j), substr)
 	}
+	h := makeHorspoolRev(substr)
+	return h.lastIndex(s.SliceTo(j), substr)
 }
 
 // IndexRune returns the index of the first instance of the Unicode code point
@@ -56,6 +84,90 @@ func IndexRune(s Str, r rune) int {
 	return strings.IndexRune(s.String(), r)
 }
 
+// IndexAny returns the index of the first instance of any Unicode code point
+// from chars in s, or -1 if no Unicode code point from chars is present in s.
+func IndexAny(s, chars Str) int {
+	if chars.Len == 0 {
+		// Avoid scanning all of s.
+		return -1
+	}
+	if chars.Len == 1 {
+		// Avoid scanning all of s.
+		r := rune(chars.Get(0))
+		if r >= utf8.RuneSelf {
+			r = utf8.RuneError
+		}
+		return IndexRune(s, r)
+	}
+	if s.Len > 8 {
+		if as, isASCII := makeASCIISet(chars); isASCII {
+			for i, c := range s.asBytes() {
+				if as.contains(c) {
+					return i
+				}
+			}
+			return -1
+		}
+	}
+	for i, c := range s.String() {
+		if IndexRune(chars, c) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// LastIndexAny returns the index of the last instance of any Unicode code
+// point from chars in s, or -1 if no Unicode code point from chars is
+// present in s.
+func LastIndexAny(s, chars Str) int {
+	if chars.Len == 0 {
+		// Avoid scanning all of s.
+		return -1
+	}
+	if s.Len == 1 {
+		rc := rune(s.Get(0))
+		if rc >= utf8.RuneSelf {
+			rc = utf8.RuneError
+		}
+		if IndexRune(chars, rc) >= 0 {
+			return 0
+		}
+		return -1
+	}
+	if s.Len > 8 {
+		if as, isASCII := makeASCIISet(chars); isASCII {
+			b := s.asBytes()
+			for i := len(b) - 1; i >= 0; i-- {
+				if as.contains(b[i]) {
+					return i
+				}
+			}
+			return -1
+		}
+	}
+	if chars.Len == 1 {
+		rc := rune(chars.Get(0))
+		if rc >= utf8.RuneSelf {
+			rc = utf8.RuneError
+		}
+		return lastIndexFunc(s, func(r rune) bool { return r == rc }, true)
+	}
+	return lastIndexFunc(s, func(r rune) bool { return IndexRune(chars, r) >= 0 }, true)
+}
+
+// IndexFunc returns the index into s of the first Unicode
+// code point satisfying f(c), or -1 if none do.
+func IndexFunc(s Str, f func(rune) bool) int {
+	return indexFunc(s, f, true)
+}
+
+// LastIndexFunc returns the index into s of the last
+// Unicode code point satisfying f(c), or -1 if none do.
+func LastIndexFunc(s Str, f func(rune) bool) int {
+	return lastIndexFunc(s, f, true)
+}
+
 // Contains reports whether substr is within s.
 func Contains(s, substr Str) bool {
 	return Index(s, substr) >= 0
@@ -120,7 +232,12 @@ func CutSuffix(s, suffix Str) (before Str, found bool) {
 // FieldsFunc makes no guarantees about the order in which it calls f(c)
 // and assumes that f always returns the same value for a given c.
 func FieldsFunc(s Str, f func(rune) bool) iter.Seq[Str] {
-	return func(yield func(Str) bool) {
+	return values(FieldsFuncIndexed(s, f))
+}
+
+// FieldsFuncIndexed is like [FieldsFunc], but also yields the offset in s of each field.
+func FieldsFuncIndexed(s Str, f func(rune) bool) iter.Seq2[int, Str] {
+	return func(yield func(int, Str) bool) {
 		// Find the field start and end indices.
 		// Doing this in a separate pass (rather than slicing the string s
 		// and collecting the result substrings right away) is significantly
@@ -129,7 +246,7 @@ func FieldsFunc(s Str, f func(rune) bool) iter.Seq[Str] {
 		for end, r := range s.String() {
 			if f(r) {
 				if start >= 0 {
-					if !yield(s.Slice(start, end)) {
+					if !yield(start, s.Slice(start, end)) {
 						return
 					}
 					// Set start to a negative value.
@@ -146,7 +263,7 @@ func FieldsFunc(s Str, f func(rune) bool) iter.Seq[Str] {
 
 		// Last field might end at EOF.
 		if start >= 0 {
-			if !yield(s.Slice(start, s.Len)) {
+			if !yield(start, s.Slice(start, s.Len)) {
 				return
 			}
 		}
@@ -165,6 +282,11 @@ var asciiSpace = func() [256]bool {
 // characters, as defined by unicode.IsSpace, returning a slice of substrings of s or an
 // empty slice if s contains only white space.
 func Fields(s Str) iter.Seq[Str] {
+	return values(FieldsIndexed(s))
+}
+
+// FieldsIndexed is like [Fields], but also yields the offset in s of each field.
+func FieldsIndexed(s Str) iter.Seq2[int, Str] {
 	// First count the fields.
 	// This is an exact count if s is ASCII, otherwise it is an approximation.
 	n := 0
@@ -183,11 +305,11 @@ func Fields(s Str) iter.Seq[Str] {
 
 	if setBits >= utf8.RuneSelf {
 		// Some runes in the input string are not ASCII.
-		return FieldsFunc(s, unicode.IsSpace)
+		return FieldsFuncIndexed(s, unicode.IsSpace)
 	}
 
 	// ASCII fast path
-	return func(yield func(Str) bool) {
+	return func(yield func(int, Str) bool) {
 		fieldStart := 0
 		i := 0
 		// Skip spaces in the front of the input.
@@ -200,7 +322,7 @@ func Fields(s Str) iter.Seq[Str] {
 				i++
 				continue
 			}
-			if !yield(s.Slice(fieldStart, i)) {
+			if !yield(fieldStart, s.Slice(fieldStart, i)) {
 				return
 			}
 			i++
@@ -211,20 +333,14 @@ func Fields(s Str) iter.Seq[Str] {
 			fieldStart = i
 		}
 		if fieldStart < s.Len { // Last field might end at EOF.
-			yield(s.SliceFrom(fieldStart))
+			yield(fieldStart, s.SliceFrom(fieldStart))
 		}
 	}
 }
 
 // LastIndexByte returns the index of the last instance of c in s, or -1 if c is not present in s.
 func LastIndexByte(s Str, c byte) int {
-	b := s.asBytes()
-	for i := s.Len - 1; i >= 0; i-- {
-		if b[i] == c {
-			return i
-		}
-	}
-	return -1
+	return bytes.LastIndexByte(s.asBytes(), c)
 }
 
 // Count counts the number of non-overlapping instances of substr in s.
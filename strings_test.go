@@ -0,0 +1,51 @@
+package str
+
+import (
+	"strings"
+	"testing"
+)
+
+// searchText returns n pseudo-random bytes over a 4-letter alphabet, on which
+// the first bytes of needles are too frequent for the byte scan of Index.
+func searchText(n int) string {
+	b := make([]byte, n)
+	x := uint32(1)
+	for i := range b {
+		x = x*1664525 + 1013904223
+		b[i] = "acgt"[x>>30]
+	}
+	return string(b)
+}
+
+func BenchmarkIndex(b *testing.B) {
+	text := searchText(1 << 16)
+	for _, bc := range []struct {
+		name   string
+		needle string
+	}{
+		{"Byte", "x"},
+		{"Short", "acgtxacgtacg"},                   // Rabin-Karp
+		{"Long", strings.Repeat("acgtt", 12) + "x"}, // Horspool
+	} {
+		// The needle only occurs at the end.
+		s := text + bc.needle
+		hay, needle := NewFromString(s), NewFromString(bc.needle)
+		want := strings.Index(s, bc.needle)
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(s)))
+			for range b.N {
+				if Index(hay, needle) != want {
+					b.Fatal("wrong index")
+				}
+			}
+		})
+		b.Run(bc.name+"/strings", func(b *testing.B) {
+			b.SetBytes(int64(len(s)))
+			for range b.N {
+				if strings.Index(s, bc.needle) != want {
+					b.Fatal("wrong index")
+				}
+			}
+		})
+	}
+}
@@ -20,6 +20,11 @@ func (as *asciiSet) contains(c byte) bool {
 	return (as[c/32] & (1 << (c % 32))) != 0
 }
 
+// add adds the ASCII character c to the set.
+func (as *asciiSet) add(c byte) {
+	as[c/32] |= 1 << (c % 32)
+}
+
 // makeASCIISet creates a set of ASCII characters and reports whether all
 // characters in chars are ASCII.
 func makeASCIISet(chars Str) (as asciiSet, ok bool) {
@@ -27,7 +32,7 @@ func makeASCIISet(chars Str) (as asciiSet, ok bool) {
 		if c >= utf8.RuneSelf {
 			return as, false
 		}
-		as[c/32] |= 1 << (c % 32)
+		as.add(c)
 	}
 	return as, true
 }
@@ -0,0 +1,141 @@
+package url
+
+import (
+	"iter"
+
+	"github.com/rprtr258/str"
+	. "github.com/rprtr258/str/internal"
+)
+
+// Query returns an iterator over the parameters of query, a query string
+// of key=value pairs separated by '&', as decoded by [url.ParseQuery]:
+// the keys and values are unescaped, with '+' standing for a space, and a
+// missing value is empty. Like url.ParseQuery, it skips the pairs with
+// invalid escapes, and those with a semicolon.
+//
+// The parameters are decoded as they are yielded. Those without escapes are
+// views of query. The others are finished in b, or built in newly allocated
+// buffers if b is nil.
+func Query(query str.Str, b *str.Builder) iter.Seq2[str.Str, str.Str] {
+	return func(yield func(str.Str, str.Str) bool) {
+		for q := query; q.Len > 0; {
+			pair := q
+			if i := str.IndexByte(q, '&'); i >= 0 {
+				pair, q = q.SliceTo(i), q.SliceFrom(i+1)
+			} else {
+				q = q.SliceFrom(q.Len)
+			}
+
+			if pair.Len == 0 || str.IndexByte(pair, ';') >= 0 {
+				continue
+			}
+			key, value := pair, pair.SliceFrom(pair.Len)
+			if i := str.IndexByte(pair, '='); i >= 0 {
+				key, value = pair.SliceTo(i), pair.SliceFrom(i+1)
+			}
+			if checkEscapes(key.String()) != nil || checkEscapes(value.String()) != nil {
+				continue
+			}
+			if !yield(unescapeQuery(key, b), unescapeQuery(value, b)) {
+				return
+			}
+		}
+	}
+}
+
+// unescapeQuery returns s, whose escapes are valid, decoded as a query
+// parameter. If s has no escapes or '+', it is returned as is. Otherwise
+// the result is finished in b, or built in a newly allocated buffer if b
+// is nil.
+func unescapeQuery(s str.Str, b *str.Builder) str.Str {
+	t := s.String()
+	i := 0
+	for i < len(t) && t[i] != '%' && t[i] != '+' {
+		i++
+	}
+	if i == len(t) {
+		return s
+	}
+
+	w := writer{b: b}
+	w.grow(len(t))
+	w.write(s.SliceTo(i))
+	for ; i < len(t); i++ {
+		switch c := t[i]; c {
+		case '%':
+			w.writeByte(Unhex(t[i+1])<<4 | Unhex(t[i+2]))
+			i += 2
+		case '+':
+			w.writeByte(' ')
+		default:
+			w.writeByte(c)
+		}
+	}
+	return w.finish()
+}
+
+// Segments returns an iterator over the segments of path, separated by
+// '/', after the leading '/' of absolute paths. The segments are kept
+// escaped, so that an escaped "%2F" is not taken for a separator.
+// An empty path has no segments, and "/" has an empty one.
+func Segments(path str.Str) iter.Seq[str.Str] {
+	return func(yield func(str.Str) bool) {
+		if path.Len == 0 {
+			return
+		}
+		p := path
+		if p.Get(0) == '/' {
+			p = p.SliceFrom(1)
+		}
+		for {
+			i := str.IndexByte(p, '/')
+			if i < 0 {
+				yield(p)
+				return
+			}
+			if !yield(p.SliceTo(i)) {
+				return
+			}
+			p = p.SliceFrom(i + 1)
+		}
+	}
+}
+
+// writer writes a string to the pending string of a Builder, or to a newly
+// allocated buffer if the Builder is nil.
+type writer struct {
+	b   *str.Builder
+	buf []byte
+}
+
+func (w *writer) grow(n int) {
+	if w.b != nil {
+		w.b.Grow(n)
+	} else {
+		w.buf = make([]byte, 0, n)
+	}
+}
+
+func (w *writer) write(s str.Str) {
+	if w.b != nil {
+		w.b.WriteStr(s)
+	} else {
+		w.buf = append(w.buf, s.String()...)
+	}
+}
+
+func (w *writer) writeByte(c byte) {
+	if w.b != nil {
+		w.b.WriteByte(c)
+	} else {
+		w.buf = append(w.buf, c)
+	}
+}
+
+// finish returns the string written.
+func (w *writer) finish() str.Str {
+	if w.b != nil {
+		return w.b.Finish()
+	}
+	return str.NewFromBytes(w.buf)
+}
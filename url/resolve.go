@@ -0,0 +1,139 @@
+package url
+
+import (
+	"github.com/rprtr258/str"
+)
+
+// Resolve returns the URL of the reference ref resolved against the base
+// URL u, as defined by section 5.2 of RFC 3986: ref is returned with its
+// dot segments removed if it has a scheme, and otherwise completed with the
+// components of u it lacks, its path taken relative to the path of u.
+// u should have a scheme. The result, which can be read with Parse, is
+// finished in b, or built in a newly allocated buffer if b is nil.
+func (u *URL) Resolve(ref *URL, b *str.Builder) str.Str {
+	var t URL
+	// The path of the target is dir followed by the path of t, with a '/'
+	// between them if merge is true.
+	var dir str.Str
+	merge := false
+	switch {
+	case ref.Scheme.Len > 0:
+		t = *ref
+	case ref.HasAuthority:
+		t = *ref
+		t.Scheme = u.Scheme
+	case ref.Path.Len == 0:
+		t = *u
+		if ref.HasQuery {
+			t.RawQuery, t.HasQuery = ref.RawQuery, true
+		}
+		t.Fragment, t.HasFragment = ref.Fragment, ref.HasFragment
+	default:
+		t = *u
+		t.Path, t.RawQuery, t.HasQuery = ref.Path, ref.RawQuery, ref.HasQuery
+		t.Fragment, t.HasFragment = ref.Fragment, ref.HasFragment
+		if ref.Path.Get(0) != '/' {
+			// The path of ref replaces the last segment of the base path,
+			// which is "/" if it is empty after an authority.
+			if i := str.LastIndexByte(u.Path, '/'); i >= 0 {
+				dir, merge = u.Path.SliceTo(i), true
+			} else {
+				merge = u.HasAuthority
+			}
+		}
+	}
+
+	w := writer{b: b}
+	w.grow(u.Scheme.Len + u.Authority.Len + u.Path.Len + u.RawQuery.Len + ref.Path.Len + ref.Authority.Len + ref.RawQuery.Len + ref.Fragment.Len + 8)
+	if t.Scheme.Len > 0 {
+		w.write(t.Scheme)
+		w.writeByte(':')
+	}
+	if t.HasAuthority {
+		w.write(slashes)
+		w.write(t.Authority)
+	}
+	if t.Path.Len > 0 {
+		if ref.Path.Len == 0 {
+			w.write(t.Path)
+		} else {
+			var toks, out [32]segment
+			s := appendSegments(toks[:0], dir, false)
+			s = appendSegments(s, t.Path, merge)
+			for _, seg := range removeDotSegments(out[:0], s) {
+				if seg.slash {
+					w.writeByte('/')
+				}
+				w.write(seg.s)
+			}
+		}
+	}
+	if t.HasQuery {
+		w.writeByte('?')
+		w.write(t.RawQuery)
+	}
+	if t.HasFragment {
+		w.writeByte('#')
+		w.write(t.Fragment)
+	}
+	return w.finish()
+}
+
+var slashes = str.NewFromString("//")
+
+// segment is a segment of a path, with the '/' before it if there is one.
+type segment struct {
+	slash bool
+	s     str.Str
+}
+
+// appendSegments appends the segments of path to segs. If slash is true,
+// path is read as if it started with a '/'.
+func appendSegments(segs []segment, path str.Str, slash bool) []segment {
+	for path.Len > 0 {
+		if path.Get(0) == '/' {
+			slash, path = true, path.SliceFrom(1)
+		}
+		i := str.IndexByte(path, '/')
+		if i < 0 {
+			i = path.Len
+		}
+		segs = append(segs, segment{slash, path.SliceTo(i)})
+		path, slash = path.SliceFrom(i), false
+	}
+	if slash {
+		// The path read as "/".
+		segs = append(segs, segment{slash: true})
+	}
+	return segs
+}
+
+// removeDotSegments appends to out the segments of a path with its "." and
+// ".." segments removed, following the algorithm of section 5.2.4 of
+// RFC 3986.
+func removeDotSegments(out, segs []segment) []segment {
+	for i, seg := range segs {
+		last := i == len(segs)-1
+		dot := seg.s.Len == 1 && seg.s.Get(0) == '.'
+		dotdot := seg.s.Len == 2 && seg.s.Get(0) == '.' && seg.s.Get(1) == '.'
+		switch {
+		case !dot && !dotdot:
+			out = append(out, seg)
+		case !seg.slash:
+			// A leading "./" or "../" is removed.
+			if !last {
+				segs[i+1].slash = false
+			}
+		default:
+			// "/./" and "/../" are replaced with "/", the latter removing
+			// the last segment.
+			if dotdot && len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, segment{slash: true})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,329 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rprtr258/str"
+	. "github.com/rprtr258/str/internal"
+)
+
+// Template is a URI template, as defined by RFC 6570, such as
+// "/users/{id}{?fields*}", split into its literals and expressions.
+type Template struct {
+	Parts []TemplatePart
+}
+
+// TemplatePart is a literal of a template, or an expression between braces.
+// Expressions have variables, literals have none.
+type TemplatePart struct {
+	// Literal is the text of a literal, as written.
+	Literal str.Str
+	// Operator is the operator of an expression, one of +#./;?&, or 0.
+	Operator byte
+	Vars     []VarSpec
+}
+
+// VarSpec is a variable of an expression, with its modifier: Explode is
+// set by a '*', and MaxLen is the length of a ':' prefix, or 0.
+type VarSpec struct {
+	Name    str.Str
+	Explode bool
+	MaxLen  int
+}
+
+// ParseTemplate parses s as a URI template of level 4, the highest of
+// RFC 6570. The parts of the template are views into s. It returns an
+// error of type *Error for unclosed or empty expressions, reserved
+// operators, and invalid variable names or modifiers.
+func ParseTemplate(s str.Str) (Template, error) {
+	var t Template
+	if err := t.parse(s); err != nil {
+		return Template{}, &Error{Op: "parse template", URL: strings.Clone(s.String()), Err: err}
+	}
+	return t, nil
+}
+
+func (t *Template) parse(s str.Str) error {
+	for s.Len > 0 {
+		i := str.IndexByte(s, '{')
+		if i < 0 {
+			i = s.Len
+		}
+		if i > 0 {
+			if str.IndexByte(s.SliceTo(i), '}') >= 0 {
+				return errors.New("unexpected '}' in template")
+			}
+			t.Parts = append(t.Parts, TemplatePart{Literal: s.SliceTo(i)})
+			s = s.SliceFrom(i)
+			continue
+		}
+
+		j := str.IndexByte(s, '}')
+		if j < 0 {
+			return errors.New("missing '}' in template")
+		}
+		expr := s.Slice(1, j)
+		s = s.SliceFrom(j + 1)
+		if expr.Len == 0 {
+			return errors.New("empty expression in template")
+		}
+
+		var p TemplatePart
+		switch c := expr.Get(0); {
+		case strings.IndexByte("+#./;?&", c) >= 0:
+			p.Operator, expr = c, expr.SliceFrom(1)
+		case strings.IndexByte("=,!@|", c) >= 0:
+			return fmt.Errorf("reserved operator %q in template", c)
+		}
+		for {
+			spec := expr
+			i := str.IndexByte(expr, ',')
+			if i >= 0 {
+				spec, expr = expr.SliceTo(i), expr.SliceFrom(i+1)
+			}
+			v, ok := parseVarSpec(spec)
+			if !ok {
+				return fmt.Errorf("invalid variable %q in template", spec.String())
+			}
+			p.Vars = append(p.Vars, v)
+			if i < 0 {
+				break
+			}
+		}
+		t.Parts = append(t.Parts, p)
+	}
+	return nil
+}
+
+// parseVarSpec parses a variable name followed by an optional modifier.
+func parseVarSpec(spec str.Str) (VarSpec, bool) {
+	s := spec.String()
+	var v VarSpec
+	n := 0
+name:
+	for n < len(s) {
+		switch c := s[n]; {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_':
+			n++
+		case c == '%' && n+2 < len(s) && IsHex(s[n+1]) && IsHex(s[n+2]):
+			n += 3
+		case c == '.' && n > 0 && s[n-1] != '.':
+			n++
+		default:
+			break name
+		}
+	}
+	if n == 0 || s[n-1] == '.' {
+		return VarSpec{}, false
+	}
+	v.Name = spec.SliceTo(n)
+
+	switch mod := s[n:]; {
+	case mod == "":
+	case mod == "*":
+		v.Explode = true
+	case mod[0] == ':' && len(mod) >= 2 && len(mod) <= 5 && mod[1] != '0':
+		for i := 1; i < len(mod); i++ {
+			if mod[i] < '0' || mod[i] > '9' {
+				return VarSpec{}, false
+			}
+			v.MaxLen = v.MaxLen*10 + int(mod[i]-'0')
+		}
+	default:
+		return VarSpec{}, false
+	}
+	return v, true
+}
+
+// Value is the value of a template variable: a string, a list of strings,
+// or an associative array of keys and values. The zero Value is undefined,
+// as are empty lists and arrays, and their expansion is skipped.
+type Value struct {
+	kind  valueKind
+	s     str.Str
+	items []str.Str
+}
+
+type valueKind uint8
+
+const (
+	undefinedValue valueKind = iota
+	stringValue
+	listValue
+	assocValue
+)
+
+// StringValue returns the Value of a string variable.
+func StringValue(s str.Str) Value {
+	return Value{kind: stringValue, s: s}
+}
+
+// ListValue returns the Value of a list variable.
+func ListValue(items ...str.Str) Value {
+	return Value{kind: listValue, items: items}
+}
+
+// AssocValue returns the Value of an associative array variable, whose
+// keys and values alternate in pairs.
+func AssocValue(pairs ...str.Str) Value {
+	Assert(len(pairs)%2 == 0, "odd number of key-value pairs")
+	return Value{kind: assocValue, items: pairs}
+}
+
+func (v Value) defined() bool {
+	return v.kind == stringValue || len(v.items) > 0
+}
+
+// templateOp describes the expansion of an operator: first is written before
+// the first defined variable and sep between the others, named variables
+// are written as name=value, or followed by ifEmpty if value is empty, and
+// the reserved characters of values are kept if reserved is true.
+type templateOp struct {
+	first, sep, ifEmpty string
+	named, reserved     bool
+}
+
+var templateOps = map[byte]templateOp{
+	0:   {sep: ","},
+	'+': {sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", ifEmpty: "=", named: true},
+	'&': {first: "&", sep: "&", ifEmpty: "=", named: true},
+}
+
+// Expand returns the URI reference obtained by expanding the expressions
+// of t with the values of vars, by variable name, as defined by RFC 6570.
+// The characters of literals not allowed in URIs are percent-encoded. The
+// result is finished in b, or built in a newly allocated buffer if b is nil.
+func (t *Template) Expand(vars map[string]Value, b *str.Builder) str.Str {
+	n := 0
+	for _, p := range t.Parts {
+		n += p.Literal.Len + 8*len(p.Vars)
+	}
+	w := writer{b: b}
+	w.grow(n)
+
+	for _, p := range t.Parts {
+		if p.Vars == nil {
+			w.writeEscaped(p.Literal, true)
+			continue
+		}
+		op := templateOps[p.Operator]
+		first := true
+		for _, v := range p.Vars {
+			val := vars[v.Name.String()]
+			if !val.defined() {
+				continue
+			}
+			if first {
+				w.write(str.NewFromString(op.first))
+			} else {
+				w.write(str.NewFromString(op.sep))
+			}
+			first = false
+			w.writeVar(&op, v, val)
+		}
+	}
+	return w.finish()
+}
+
+// writeVar writes the expansion of the defined variable v of value val.
+func (w *writer) writeVar(op *templateOp, v VarSpec, val Value) {
+	switch {
+	case val.kind == stringValue:
+		s := val.s
+		if v.MaxLen > 0 {
+			s = prefix(s, v.MaxLen)
+		}
+		if op.named {
+			w.write(v.Name)
+			w.writeAssign(op, s)
+		} else {
+			w.writeEscaped(s, op.reserved)
+		}
+	case !v.Explode:
+		// Lists and associative arrays are written as comma-separated
+		// items, keys and values.
+		if op.named {
+			w.write(v.Name)
+			w.writeByte('=')
+		}
+		for i, item := range val.items {
+			if i > 0 {
+				w.writeByte(',')
+			}
+			w.writeEscaped(item, op.reserved)
+		}
+	case val.kind == listValue:
+		for i, item := range val.items {
+			if i > 0 {
+				w.write(str.NewFromString(op.sep))
+			}
+			if op.named {
+				w.write(v.Name)
+				w.writeAssign(op, item)
+			} else {
+				w.writeEscaped(item, op.reserved)
+			}
+		}
+	default:
+		for i := 0; i < len(val.items); i += 2 {
+			if i > 0 {
+				w.write(str.NewFromString(op.sep))
+			}
+			w.writeEscaped(val.items[i], op.reserved)
+			if op.named {
+				w.writeAssign(op, val.items[i+1])
+			} else {
+				w.writeByte('=')
+				w.writeEscaped(val.items[i+1], op.reserved)
+			}
+		}
+	}
+}
+
+// writeAssign writes the value s of a named variable: '=' followed by s,
+// or the ifEmpty string of op if s is empty.
+func (w *writer) writeAssign(op *templateOp, s str.Str) {
+	if s.Len == 0 {
+		w.write(str.NewFromString(op.ifEmpty))
+		return
+	}
+	w.writeByte('=')
+	w.writeEscaped(s, op.reserved)
+}
+
+// writeEscaped writes s with its bytes percent-encoded, except for the
+// unreserved characters and, if reserved is true, the reserved characters
+// and the percent-encoded bytes of s.
+func (w *writer) writeEscaped(s str.Str, reserved bool) {
+	t := s.String()
+	for i := 0; i < len(t); i++ {
+		switch c := t[i]; {
+		case IsUnreserved(c) || reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0,
+			reserved && c == '%' && i+2 < len(t) && IsHex(t[i+1]) && IsHex(t[i+2]):
+			w.writeByte(c)
+		default:
+			w.writeByte('%')
+			w.writeByte(UpperHex[c>>4])
+			w.writeByte(UpperHex[c&15])
+		}
+	}
+}
+
+// prefix returns the first n characters of s.
+func prefix(s str.Str, n int) str.Str {
+	t := s.String()
+	i := 0
+	for ; n > 0 && i < len(t); n-- {
+		_, size := utf8.DecodeRuneInString(t[i:])
+		i += size
+	}
+	return s.SliceTo(i)
+}
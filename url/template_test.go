@@ -0,0 +1,208 @@
+package url
+
+import (
+	"testing"
+
+	"github.com/rprtr258/str"
+)
+
+// The variables of the examples of section 3.2 of RFC 6570.
+var templateVars = map[string]Value{
+	"count":      ListValue(newStr("one"), newStr("two"), newStr("three")),
+	"dom":        ListValue(newStr("example"), newStr("com")),
+	"dub":        StringValue(newStr("me/too")),
+	"hello":      StringValue(newStr("Hello World!")),
+	"half":       StringValue(newStr("50%")),
+	"var":        StringValue(newStr("value")),
+	"who":        StringValue(newStr("fred")),
+	"base":       StringValue(newStr("http://example.com/home/")),
+	"path":       StringValue(newStr("/foo/bar")),
+	"list":       ListValue(newStr("red"), newStr("green"), newStr("blue")),
+	"keys":       AssocValue(newStr("semi"), newStr(";"), newStr("dot"), newStr("."), newStr("comma"), newStr(",")),
+	"v":          StringValue(newStr("6")),
+	"x":          StringValue(newStr("1024")),
+	"y":          StringValue(newStr("768")),
+	"empty":      StringValue(newStr("")),
+	"empty_keys": AssocValue(),
+}
+
+func newStr(v string) str.Str { return str.NewFromString(v) }
+
+func TestTemplateExpand(t *testing.T) {
+	for _, tc := range []struct {
+		template, want string
+	}{
+		// Section 3.2.1.
+		{"{count}", "one,two,three"},
+		{"{count*}", "one,two,three"},
+		{"{/count}", "/one,two,three"},
+		{"{/count*}", "/one/two/three"},
+		{"{;count}", ";count=one,two,three"},
+		{"{;count*}", ";count=one;count=two;count=three"},
+		{"{?count}", "?count=one,two,three"},
+		{"{?count*}", "?count=one&count=two&count=three"},
+		{"{&count*}", "&count=one&count=two&count=three"},
+		// Section 3.2.2.
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"?{undef,y}", "?768"},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "semi,%3B,dot,.,comma,%2C"},
+		{"{keys*}", "semi=%3B,dot=.,comma=%2C"},
+		// Section 3.2.3.
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"O{+empty}X", "OX"},
+		{"O{+undef}X", "OX"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"up{+path}{var}/here", "up/foo/barvalue/here"},
+		{"{+x,hello,y}", "1024,Hello%20World!,768"},
+		{"{+path,x}/here", "/foo/bar,1024/here"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{+list}", "red,green,blue"},
+		{"{+list*}", "red,green,blue"},
+		{"{+keys}", "semi,;,dot,.,comma,,"},
+		{"{+keys*}", "semi=;,dot=.,comma=,"},
+		// Section 3.2.4.
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#half}", "#50%25"},
+		{"foo{#empty}", "foo#"},
+		{"foo{#undef}", "foo"},
+		{"{#x,hello,y}", "#1024,Hello%20World!,768"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#list}", "#red,green,blue"},
+		{"{#list*}", "#red,green,blue"},
+		{"{#keys}", "#semi,;,dot,.,comma,,"},
+		{"{#keys*}", "#semi=;,dot=.,comma=,"},
+		// Section 3.2.5.
+		{"{.who}", ".fred"},
+		{"{.who,who}", ".fred.fred"},
+		{"{.half,who}", ".50%25.fred"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.var}", "X.value"},
+		{"X{.empty}", "X."},
+		{"X{.undef}", "X"},
+		{"X{.var:3}", "X.val"},
+		{"X{.list}", "X.red,green,blue"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"X{.keys}", "X.semi,%3B,dot,.,comma,%2C"},
+		{"X{.keys*}", "X.semi=%3B.dot=..comma=%2C"},
+		{"X{.empty_keys}", "X"},
+		{"X{.empty_keys*}", "X"},
+		// Section 3.2.6.
+		{"{/who}", "/fred"},
+		{"{/who,who}", "/fred/fred"},
+		{"{/half,who}", "/50%25/fred"},
+		{"{/who,dub}", "/fred/me%2Ftoo"},
+		{"{/var}", "/value"},
+		{"{/var,empty}", "/value/"},
+		{"{/var,undef}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/var:1,var}", "/v/value"},
+		{"{/list}", "/red,green,blue"},
+		{"{/list*}", "/red/green/blue"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{/keys}", "/semi,%3B,dot,.,comma,%2C"},
+		{"{/keys*}", "/semi=%3B/dot=./comma=%2C"},
+		// Section 3.2.7.
+		{"{;who}", ";who=fred"},
+		{"{;half}", ";half=50%25"},
+		{"{;empty}", ";empty"},
+		{"{;v,empty,who}", ";v=6;empty;who=fred"},
+		{"{;v,bar,who}", ";v=6;who=fred"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;x,y,undef}", ";x=1024;y=768"},
+		{"{;hello:5}", ";hello=Hello"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys}", ";keys=semi,%3B,dot,.,comma,%2C"},
+		{"{;keys*}", ";semi=%3B;dot=.;comma=%2C"},
+		// Section 3.2.8.
+		{"{?who}", "?who=fred"},
+		{"{?half}", "?half=50%25"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?x,y,undef}", "?x=1024&y=768"},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys}", "?keys=semi,%3B,dot,.,comma,%2C"},
+		{"{?keys*}", "?semi=%3B&dot=.&comma=%2C"},
+		// Section 3.2.9.
+		{"{&who}", "&who=fred"},
+		{"{&half}", "&half=50%25"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+		{"{&var:3}", "&var=val"},
+		{"{&list}", "&list=red,green,blue"},
+		{"{&list*}", "&list=red&list=green&list=blue"},
+		{"{&keys}", "&keys=semi,%3B,dot,.,comma,%2C"},
+		{"{&keys*}", "&semi=%3B&dot=.&comma=%2C"},
+		// Literals and prefixes of multibyte characters.
+		{"/a b/%41{var}", "/a%20b/%41value"},
+		{"{who:2}é", "fr%C3%A9"},
+	} {
+		tmpl, err := ParseTemplate(newStr(tc.template))
+		if err != nil {
+			t.Errorf("ParseTemplate(%q): %v", tc.template, err)
+			continue
+		}
+		var b str.Builder
+		for _, b := range []*str.Builder{nil, &b} {
+			if got := tmpl.Expand(templateVars, b).String(); got != tc.want {
+				t.Errorf("Expand(%q) = %q, want %q", tc.template, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(newStr("/users/{id}{?fields*,q:10}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TemplatePart{
+		{Literal: newStr("/users/")},
+		{Vars: []VarSpec{{Name: newStr("id")}}},
+		{Operator: '?', Vars: []VarSpec{{Name: newStr("fields"), Explode: true}, {Name: newStr("q"), MaxLen: 10}}},
+	}
+	if len(tmpl.Parts) != len(want) {
+		t.Fatalf("ParseTemplate: %d parts, want %d", len(tmpl.Parts), len(want))
+	}
+	for i, p := range tmpl.Parts {
+		w := want[i]
+		ok := p.Literal.String() == w.Literal.String() && p.Operator == w.Operator && len(p.Vars) == len(w.Vars)
+		for j := 0; ok && j < len(p.Vars); j++ {
+			ok = p.Vars[j].Name.String() == w.Vars[j].Name.String() && p.Vars[j].Explode == w.Vars[j].Explode && p.Vars[j].MaxLen == w.Vars[j].MaxLen
+		}
+		if !ok {
+			t.Errorf("part %d = %+v, want %+v", i, p, w)
+		}
+	}
+
+	for _, bad := range []string{
+		"{", "{x", "x}", "{}", "{=x}", "{!x}", "{x,}", "{,x}", "{.}", "{x.}", "{x..y}",
+		"{x:0}", "{x:10000}", "{x:}", "{x*:3}", "{x y}", "{%zz}",
+	} {
+		if _, err := ParseTemplate(newStr(bad)); err == nil {
+			t.Errorf("ParseTemplate(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package url parses URLs and URI references, as defined by RFC 3986, held
+// in Str values, with the validation of package net/url. The components of
+// a URL are views into it, kept escaped: they can be decoded when needed
+// with [str.UnescapeURL], and query strings with [Query]. URI templates, as
+// defined by RFC 6570, are parsed and expanded with [ParseTemplate].
+package url
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/rprtr258/str"
+	. "github.com/rprtr258/str/internal"
+)
+
+// Error reports an error and the operation and URL that caused it.
+// It is the type of package net/url.
+type Error = url.Error
+
+// The errors returned in Error.Err for invalid escapes and host names,
+// those of package net/url.
+type (
+	EscapeError      = url.EscapeError
+	InvalidHostError = url.InvalidHostError
+)
+
+// URL is a URL or a relative reference, split into its components:
+//
+//	scheme://user@host:port/path?query#fragment
+//
+// Undefined components are empty, and HasAuthority, HasQuery and HasFragment
+// tell empty components from undefined ones, as in "file:///etc" or "/?#".
+type URL struct {
+	// Scheme is the scheme, as written: schemes are case-insensitive.
+	Scheme str.Str
+	// Authority is the whole authority, from which User, Host and Port
+	// are taken.
+	Authority str.Str
+	// User is the user information, before the '@', such as "user:password".
+	User str.Str
+	// Host is the host, without the brackets of IP literals, which keep
+	// their escaped zone, such as "fe80::1%25en0".
+	Host str.Str
+	// Port is the decimal port, without the ':'.
+	Port     str.Str
+	Path     str.Str
+	RawQuery str.Str
+	Fragment str.Str
+
+	HasAuthority bool
+	HasQuery     bool
+	HasFragment  bool
+}
+
+// IsAbs reports whether the URL has a scheme.
+func (u *URL) IsAbs() bool {
+	return u.Scheme.Len > 0
+}
+
+// Parse parses s as a URL or a relative reference, without allocating
+// unless s is invalid. It rejects the URLs rejected by [url.Parse], those
+// with control characters, invalid escapes, hosts or ports, or without
+// a scheme but with a colon in their first path segment, with errors of
+// type *Error.
+//
+// Unlike url.Parse, Parse does not lower the case of the scheme, sets Path
+// to the paths which do not start with '/' after a scheme, like "mailto:x",
+// checking their escapes, and reads the empty authority of references
+// starting with "///".
+func Parse(s str.Str) (URL, error) {
+	var u URL
+	// As url.Parse does, control characters are allowed in the fragment,
+	// its escapes are checked last, and the errors found before are
+	// reported with the URL without its fragment.
+	rest := s
+	if i := str.IndexByte(s, '#'); i >= 0 {
+		u.Fragment, u.HasFragment = s.SliceFrom(i+1), true
+		rest = s.SliceTo(i)
+	}
+	if err := u.parse(rest); err != nil {
+		return URL{}, &Error{Op: "parse", URL: strings.Clone(rest.String()), Err: err}
+	}
+	if err := checkEscapes(u.Fragment.String()); err != nil {
+		return URL{}, &Error{Op: "parse", URL: strings.Clone(s.String()), Err: err}
+	}
+	return u, nil
+}
+
+// parse sets the components of u but the fragment to those of s.
+func (u *URL) parse(s str.Str) error {
+	raw := s.String()
+	for i := 0; i < len(raw); i++ {
+		if c := raw[i]; c < ' ' || c == 0x7f {
+			return errors.New("net/url: invalid control character in URL")
+		}
+	}
+
+	n, err := schemeLen(raw)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		u.Scheme = s.SliceTo(n)
+		s = s.SliceFrom(n + 1)
+	}
+
+	if i := str.IndexByte(s, '?'); i >= 0 {
+		u.RawQuery, u.HasQuery = s.SliceFrom(i+1), true
+		s = s.SliceTo(i)
+	}
+
+	rest := s.String()
+	switch {
+	case strings.HasPrefix(rest, "//"):
+		a := s.SliceFrom(2)
+		if i := str.IndexByte(a, '/'); i >= 0 {
+			a, s = a.SliceTo(i), a.SliceFrom(i)
+		} else {
+			s = a.SliceFrom(a.Len)
+		}
+		if err := u.parseAuthority(a); err != nil {
+			return err
+		}
+	case n == 0:
+		// The first segment of a relative path could be taken for a scheme.
+		if seg, _, _ := strings.Cut(rest, "/"); strings.IndexByte(seg, ':') >= 0 {
+			return errors.New("first path segment in URL cannot contain colon")
+		}
+	}
+
+	u.Path = s
+	return checkEscapes(s.String())
+}
+
+// schemeLen returns the length of the scheme at the start of s, followed by
+// a colon, or 0 if there is none.
+func schemeLen(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.':
+			if i == 0 {
+				return 0, nil
+			}
+		case c == ':':
+			if i == 0 {
+				return 0, errors.New("missing protocol scheme")
+			}
+			return i, nil
+		default:
+			return 0, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseAuthority sets the authority of u to a.
+func (u *URL) parseAuthority(a str.Str) error {
+	u.Authority, u.HasAuthority = a, true
+	host := a
+	i := str.LastIndexByte(a, '@')
+	if i >= 0 {
+		host = a.SliceFrom(i + 1)
+	}
+	if err := u.parseHost(host); err != nil {
+		return err
+	}
+	if i < 0 {
+		return nil
+	}
+
+	u.User = a.SliceTo(i)
+	user := u.User.String()
+	for i := 0; i < len(user); i++ {
+		if !isUserinfo(user[i]) {
+			return errors.New("net/url: invalid userinfo")
+		}
+	}
+	return checkEscapes(user)
+}
+
+// parseHost sets the host and port of u to those of host.
+func (u *URL) parseHost(host str.Str) error {
+	h := host.String()
+	switch i := strings.LastIndexByte(h, '['); {
+	case i > 0:
+		return errors.New("invalid IP-literal")
+	case i == 0:
+		j := strings.LastIndexByte(h, ']')
+		if j < 0 {
+			return errors.New("missing ']' in host")
+		}
+		if colonPort := h[j+1:]; !validOptionalPort(colonPort) {
+			return fmt.Errorf("invalid port %q after host", colonPort)
+		}
+
+		// The zone of an IPv6 address is introduced by an escaped '%'.
+		addr, zone, hasZone := strings.Cut(h[1:j], "%25")
+		if err := checkHost(addr, false); err != nil {
+			return err
+		}
+		if err := checkHost(zone, true); err != nil {
+			return err
+		}
+		if hasZone && zone == "" {
+			// Let ParseAddr reject the empty zone.
+			addr += "%"
+		}
+		ip, err := netip.ParseAddr(addr)
+		if err != nil {
+			return fmt.Errorf("invalid host: %w", err)
+		}
+		if ip.Is4() {
+			return errors.New("invalid IP-literal")
+		}
+
+		u.Host = host.Slice(1, j)
+		if j+1 < len(h) {
+			u.Port = host.SliceFrom(j + 2)
+		}
+		return nil
+	}
+
+	// Hosts may be lists of hosts with ports: the port is after the last colon.
+	i := strings.LastIndexByte(h, ':')
+	if i >= 0 {
+		if colonPort := h[i:]; !validOptionalPort(colonPort) {
+			return fmt.Errorf("invalid port %q after host", colonPort)
+		}
+	}
+	if err := checkHost(h, false); err != nil {
+		return err
+	}
+
+	u.Host = host
+	if i >= 0 {
+		u.Host, u.Port = host.SliceTo(i), host.SliceFrom(i+1)
+	}
+	return nil
+}
+
+// validOptionalPort reports whether port is empty or a colon followed
+// by digits.
+func validOptionalPort(port string) bool {
+	if port == "" {
+		return true
+	}
+	if port[0] != ':' {
+		return false
+	}
+	for i := 1; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkEscapes returns an EscapeError if a '%' of s is not followed by two
+// hexadecimal digits.
+func checkEscapes(s string) error {
+	for i := strings.IndexByte(s, '%'); i >= 0; i = strings.IndexByte(s, '%') {
+		if i+2 >= len(s) || !IsHex(s[i+1]) || !IsHex(s[i+2]) {
+			return EscapeError(s[i:min(i+3, len(s))])
+		}
+		s = s[i+3:]
+	}
+	return nil
+}
+
+// isHostChar reports whether the ASCII character c may be written as is
+// in a host.
+func isHostChar(c byte) bool {
+	return IsUnreserved(c) || strings.IndexByte(`!$&'()*+,;=:[]<>"`, c) >= 0
+}
+
+// isUserinfo reports whether c may be written in user information.
+func isUserinfo(c byte) bool {
+	return IsUnreserved(c) || strings.IndexByte("!$&'()*+,;=:%@", c) >= 0
+}
+
+// checkHost returns an error for the characters of a host, or zone if
+// zone is true, which should be escaped, and for the escaped ASCII
+// characters of a host, as url.Parse does.
+func checkHost(s string, zone bool) error {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '%':
+			if i+2 >= len(s) || !IsHex(s[i+1]) || !IsHex(s[i+2]) {
+				return EscapeError(s[i:min(i+3, len(s))])
+			}
+			v := Unhex(s[i+1])<<4 | Unhex(s[i+2])
+			if s[i:i+3] != "%25" && (!zone && v < 0x80 || zone && v != ' ' && (v >= 0x80 || !isHostChar(v))) {
+				return EscapeError(s[i : i+3])
+			}
+			i += 2
+		case c < 0x80 && !isHostChar(c):
+			return InvalidHostError(s[i : i+1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package url
+
+import (
+	"math/rand"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rprtr258/str"
+)
+
+var urlParts = []string{
+	"http", "HTTPS", "a", "mailto", "x+y", "1a", ":", "//", "/", "///", "?", "#", "@", "u:p",
+	"[", "]", "::1", "fe80::1%25en0", "%25", "1.2.3.4", "host", "h.example", ":80", ":8x",
+	".", "..", "%2F", "%zz", "%4", "é", " ", "\x01", "=", "&", ";", "+", "a=b", "%41", "<",
+	"\"", "|", "*", "%C3%A9", "%e9", "http://", "[::1]", "[fe80::1%25en0]:8080", "[1.2.3.4]",
+	"user@", "[::1%25]", "[::1%25%41]", "[::1%25%20x]", "a:b:80",
+}
+
+// randURL returns a string made of up to 8 parts of URLs, valid or not.
+func randURL(r *rand.Rand) string {
+	var b strings.Builder
+	for n := r.Intn(8); n >= 0; n-- {
+		b.WriteString(urlParts[r.Intn(len(urlParts))])
+	}
+	return b.String()
+}
+
+// netURLDiffers reports whether Parse is documented to differ from url.Parse
+// on s: for paths after a scheme which do not start with '/', whose escapes
+// are checked, and for references starting with "///", which have an empty
+// authority.
+func netURLDiffers(s string) bool {
+	s, _, _ = strings.Cut(s, "#")
+	u, err := url.Parse(s)
+	return strings.HasPrefix(s, "///") || err == nil && u.Opaque != ""
+}
+
+func TestParseNetURL(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 50000 {
+		s := randURL(r)
+		got, err := Parse(newStr(s))
+		want, wantErr := url.Parse(s)
+		if netURLDiffers(s) {
+			continue
+		}
+		if (err == nil) != (wantErr == nil) {
+			t.Fatalf("Parse(%q) error = %v, url.Parse error = %v", s, err, wantErr)
+		}
+		if err != nil {
+			if err.Error() != wantErr.Error() {
+				t.Errorf("Parse(%q) error = %v, want %v", s, err, wantErr)
+			}
+			continue
+		}
+
+		check := func(what, got, want string) {
+			if got != want {
+				t.Fatalf("Parse(%q) %s = %q, url.Parse: %q", s, what, got, want)
+			}
+		}
+		check("scheme", strings.ToLower(got.Scheme.String()), want.Scheme)
+		path, _ := str.UnescapeURL(got.Path, nil)
+		check("path", path.String(), want.Path)
+		check("query", got.RawQuery.String(), want.RawQuery)
+		fragment, _ := str.UnescapeURL(got.Fragment, nil)
+		check("fragment", fragment.String(), want.Fragment)
+		host, _ := str.UnescapeURL(got.Host, nil)
+		check("host", host.String(), want.Hostname())
+		check("port", got.Port.String(), want.Port())
+		if want.User != nil {
+			name, password, hasPassword := strings.Cut(got.User.String(), ":")
+			name, _ = url.PathUnescape(name)
+			password, _ = url.PathUnescape(password)
+			wantPassword, wantHasPassword := want.User.Password()
+			check("user name", name, want.User.Username())
+			check("password", password, wantPassword)
+			if hasPassword != wantHasPassword {
+				t.Fatalf("Parse(%q) has password %v, url.Parse: %v", s, hasPassword, wantHasPassword)
+			}
+		}
+		if got.HasQuery != want.ForceQuery && got.RawQuery.Len == 0 {
+			t.Fatalf("Parse(%q) HasQuery = %v, url.Parse ForceQuery: %v", s, got.HasQuery, want.ForceQuery)
+		}
+
+		values, _ := url.ParseQuery(want.RawQuery)
+		gotValues := url.Values{}
+		for k, v := range Query(got.RawQuery, nil) {
+			gotValues.Add(k.String(), v.String())
+		}
+		for k, v := range values {
+			if !slices.Equal(gotValues[k], v) {
+				t.Fatalf("Query(%q)[%q] = %q, url.ParseQuery: %q", got.RawQuery.String(), k, gotValues[k], v)
+			}
+		}
+		if len(gotValues) != len(values) {
+			t.Fatalf("Query(%q) = %v, url.ParseQuery: %v", got.RawQuery.String(), gotValues, values)
+		}
+	}
+}
+
+func TestParseNetURLDifferences(t *testing.T) {
+	// url.Parse does not check the escapes of opaque paths.
+	if _, err := url.Parse("mailto:%1."); err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	if _, err := Parse(newStr("mailto:%1.")); err == nil {
+		t.Error("Parse(\"mailto:%1.\") succeeded, want error")
+	}
+	u, err := Parse(newStr("mailto:x%40y"))
+	if err != nil || u.Path.String() != "x%40y" {
+		t.Errorf("Parse(\"mailto:x%%40y\") Path = %q, %v, want \"x%%40y\"", u.Path.String(), err)
+	}
+
+	// url.Parse reads "///x" as a path.
+	if want, err := url.Parse("///x"); err != nil || want.Path != "///x" {
+		t.Fatalf("url.Parse: %+v, %v", want, err)
+	}
+	u, err = Parse(newStr("///x"))
+	if err != nil || !u.HasAuthority || u.Host.Len != 0 || u.Path.String() != "/x" {
+		t.Errorf("Parse(\"///x\") = %+v, %v, want empty authority and path \"/x\"", u, err)
+	}
+}
+
+func TestResolveNetURL(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	segments := []string{"a", "b", ".", "..", "", "c;x", "%2F"}
+	randPath := func() string {
+		var b strings.Builder
+		if r.Intn(2) == 0 {
+			b.WriteByte('/')
+		}
+		for i := range r.Intn(5) {
+			if i > 0 {
+				b.WriteByte('/')
+			}
+			b.WriteString(segments[r.Intn(len(segments))])
+		}
+		return b.String()
+	}
+
+	var b str.Builder
+	for range 50000 {
+		base := "http://h/" + strings.TrimPrefix(randPath(), "/")
+		if r.Intn(3) == 0 {
+			base += "?q"
+		}
+		ref := randPath()
+		if strings.HasPrefix(ref, "//") {
+			continue
+		}
+		if r.Intn(3) == 0 {
+			ref += "?y"
+		}
+		if r.Intn(3) == 0 {
+			ref += "#f"
+		}
+		if (ref == "" || ref[0] == '?' || ref[0] == '#') && strings.Contains(base, "/.") {
+			// ResolveReference removes the dot segments of the base path,
+			// which RFC 3986 keeps when the reference has no path.
+			continue
+		}
+
+		u, err := Parse(newStr(base))
+		if err != nil {
+			t.Fatal(err)
+		}
+		refURL, err := Parse(newStr(ref))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantBase, _ := url.Parse(base)
+		wantRef, _ := url.Parse(ref)
+		want := wantBase.ResolveReference(wantRef).String()
+		for _, b := range []*str.Builder{nil, &b} {
+			got := u.Resolve(&refURL, b).String()
+			if strings.HasPrefix(got, "http://h//") {
+				// ResolveReference drops the empty first segment of the
+				// paths starting with "//", which RFC 3986 keeps.
+				break
+			}
+			if got != want {
+				t.Fatalf("Resolve(%q, %q) = %q, ResolveReference: %q", base, ref, got, want)
+			}
+		}
+	}
+}
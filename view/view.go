@@ -1,6 +1,7 @@
 package view
 
 import (
+	"cmp"
 	"iter"
 	"unsafe"
 
@@ -10,6 +11,13 @@ import (
 type View[T any] struct {
 	Base unsafe.Pointer
 	Len  int
+	// past is set on empty views at the end of the memory of another view,
+	// which start just past the element at Base: a pointer past that memory
+	// could point into an unrelated object.
+	past bool
+	// extra is the number of elements of the viewed memory past the end of
+	// the view, which views made from slices keep from their capacity.
+	extra int
 }
 
 func NewFromBaseLen[T any](base unsafe.Pointer, len int) View[T] {
@@ -17,10 +25,11 @@ func NewFromBaseLen[T any](base unsafe.Pointer, len int) View[T] {
 }
 
 func New[T any](elems ...T) View[T] {
-	return NewFromBaseLen[T](
-		unsafe.Pointer(unsafe.SliceData(elems)),
-		len(elems),
-	)
+	return View[T]{
+		Base:  unsafe.Pointer(unsafe.SliceData(elems)),
+		Len:   len(elems),
+		extra: cap(elems) - len(elems),
+	}
 }
 
 func NewFromSlice[T any](buf []T) View[T] {
@@ -28,10 +37,60 @@ func NewFromSlice[T any](buf []T) View[T] {
 }
 
 func (s View[T]) Slice(from, to int) View[T] {
-	Assert(from <= to, "invalid slice indices")
+	Assert(0 <= from && from <= to && to <= s.Len, "slice bounds out of range")
+
+	if from == s.Len {
+		if s.Len == 0 {
+			return s
+		}
+		return View[T]{Base: s.elemPtr(from - 1), past: true, extra: s.extra}
+	}
+
+	return View[T]{Base: s.elemPtr(from), Len: to - from, extra: s.extra + s.Len - to}
+}
+
+// Cap returns the number of elements from the start of s to the end of the
+// memory it views: the capacity of the slice a view was made from, less the
+// elements before s. Views never grow, so Slice is bounded by Len, not Cap.
+func (s View[T]) Cap() int {
+	return s.Len + s.extra
+}
+
+// elemPtr returns a pointer to the element i of s.
+func (s View[T]) elemPtr(i int) unsafe.Pointer {
+	var zero T
+	return unsafe.Add(s.Base, uintptr(i)*unsafe.Sizeof(zero))
+}
+
+// start returns the address of the start of s.
+func (s View[T]) start() uintptr {
+	if s.past {
+		return uintptr(s.elemPtr(1))
+	}
+	return uintptr(s.Base)
+}
+
+// OffsetIn reports whether s is a view of the memory of parent, and if so
+// returns the index in parent of its first element, which is parent.Len for
+// empty views at the end of parent.
+func (s View[T]) OffsetIn(parent View[T]) (int, bool) {
+	if s.Base == nil || parent.Base == nil {
+		return 0, false
+	}
 
-	start := uintptr(s.Base)
-	return NewFromBaseLen[T](unsafe.Pointer(start+uintptr(from)), to-from)
+	var zero T
+	size := unsafe.Sizeof(zero)
+	off := s.start() - parent.start()
+	if size > 0 && off%size != 0 {
+		return 0, false
+	}
+	if size > 0 {
+		off /= size
+	}
+	if off > uintptr(parent.Len) || uintptr(s.Len) > uintptr(parent.Len)-off {
+		return 0, false
+	}
+	return int(off), true
 }
 
 func (s View[T]) SliceFrom(from int) View[T] {
@@ -56,6 +115,43 @@ func (s View[T]) All() iter.Seq[T] {
 	}
 }
 
+func (s View[T]) Reverse() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := s.Len - 1; i >= 0; i-- {
+			if !yield(s.unsafeGet(i)) {
+				break
+			}
+		}
+	}
+}
+
+// Chunks yields consecutive non-overlapping subviews of n elements,
+// the last one may be shorter.
+func (s View[T]) Chunks(n int) iter.Seq[View[T]] {
+	Assert(n > 0, "invalid chunk size")
+
+	return func(yield func(View[T]) bool) {
+		for i := 0; i < s.Len; i += n {
+			if !yield(s.Slice(i, min(i+n, s.Len))) {
+				break
+			}
+		}
+	}
+}
+
+// Windows yields all overlapping subviews of n elements, in order.
+func (s View[T]) Windows(n int) iter.Seq[View[T]] {
+	Assert(n > 0, "invalid window size")
+
+	return func(yield func(View[T]) bool) {
+		for i := 0; i+n <= s.Len; i++ {
+			if !yield(s.Slice(i, i+n)) {
+				break
+			}
+		}
+	}
+}
+
 func (s View[T]) unsafeGet(i int) T {
 	return s.AsSlice()[i]
 }
@@ -65,6 +161,13 @@ func (s View[T]) Get(i int) T {
 	return s.unsafeGet(i)
 }
 
+// Set must only be used on views of mutable memory,
+// views of strings are read-only.
+func (s View[T]) Set(i int, v T) {
+	Assert(0 <= i && i < s.Len, "index out of bounds")
+	s.AsSlice()[i] = v
+}
+
 func Any[T any](v View[T], f func(T, int) bool) bool {
 	for i := range v.Len {
 		if f(v.unsafeGet(i), i) {
@@ -100,3 +203,43 @@ func Index[T comparable](v View[T], t T) int {
 	}
 	return -1
 }
+
+func Equal[T comparable](a, b View[T]) bool {
+	if a.Len != b.Len {
+		return false
+	}
+	return All(a, func(t T, i int) bool {
+		return b.unsafeGet(i) == t
+	})
+}
+
+func Compare[T cmp.Ordered](a, b View[T]) int {
+	for i := range min(a.Len, b.Len) {
+		if c := cmp.Compare(a.unsafeGet(i), b.unsafeGet(i)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.Len, b.Len)
+}
+
+// BinarySearch searches for target in a sorted view and returns the position
+// where target is found, or the position where target would appear in the
+// sort order; it also returns a bool saying whether the target is really
+// found in the view.
+func BinarySearch[T cmp.Ordered](v View[T], target T) (int, bool) {
+	return BinarySearchFunc(v, target, cmp.Compare[T])
+}
+
+// BinarySearchFunc works like BinarySearch, but uses a custom comparison function.
+func BinarySearchFunc[T, E any](v View[T], target E, cmp func(T, E) int) (int, bool) {
+	i, j := 0, v.Len
+	for i < j {
+		h := int(uint(i+j) >> 1) // avoid overflow when computing h
+		if cmp(v.unsafeGet(h), target) < 0 {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	return i, i < v.Len && cmp(v.unsafeGet(i), target) == 0
+}
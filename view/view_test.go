@@ -0,0 +1,38 @@
+package view
+
+import "testing"
+
+func TestSliceEndOffsetIn(t *testing.T) {
+	parent := New[int64](1, 2, 3)
+	for from := 0; from <= parent.Len; from++ {
+		s := parent.SliceFrom(from)
+		if off, ok := s.OffsetIn(parent); !ok || off != from {
+			t.Errorf("SliceFrom(%d).OffsetIn = %d, %v, want %d, true", from, off, ok, from)
+		}
+		if len(s.AsSlice()) != parent.Len-from {
+			t.Errorf("SliceFrom(%d) has %d elements", from, len(s.AsSlice()))
+		}
+	}
+	if off, ok := parent.SliceFrom(3).Slice(0, 0).OffsetIn(parent.SliceFrom(1)); !ok || off != 2 {
+		t.Errorf("OffsetIn = %d, %v, want 2, true", off, ok)
+	}
+}
+
+func TestCap(t *testing.T) {
+	parent := NewFromSlice(make([]int32, 3, 8))
+	for _, tt := range []struct {
+		v             View[int32]
+		wantLen, want int
+	}{
+		{parent, 3, 8},
+		{parent.Slice(1, 2), 1, 7},
+		{parent.SliceFrom(3), 0, 5},
+		{parent.SliceTo(0), 0, 8},
+		{parent.Slice(1, 3).SliceFrom(1), 1, 6},
+		{NewFromBaseLen[int32](parent.Base, 2), 2, 2},
+	} {
+		if tt.v.Len != tt.wantLen || tt.v.Cap() != tt.want {
+			t.Errorf("Len, Cap = %d, %d, want %d, %d", tt.v.Len, tt.v.Cap(), tt.wantLen, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package str
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Display width classes in widthTable. Code points not listed are one column wide.
+const (
+	widthNarrow uint8 = iota
+	widthZero
+	widthWide
+)
+
+// runeWidth returns the number of columns taken by r on its own.
+func runeWidth(r rune) int {
+	switch {
+	case r < utf8.RuneSelf:
+		if r < 0x20 || r == 0x7F {
+			return 0
+		}
+		return 1
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		// Regional indicators show as a letter, and as a flag in pairs.
+		return 1
+	}
+
+	switch lookupProp(widthTable, r) {
+	case widthZero:
+		return 0
+	case widthWide:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// graphemeWidth returns the number of columns taken by the grapheme cluster g:
+// the width of its first character, except for emoji, which take two columns
+// as flags or when requested by the U+FE0F VARIATION SELECTOR-16.
+func graphemeWidth(g string) int {
+	r, n := decodeRune(g)
+	w := runeWidth(r)
+	if w == 1 && len(g) > n {
+		if r >= 0x1F1E6 && r <= 0x1F1FF || strings.Contains(g[n:], "\uFE0F") {
+			return 2
+		}
+	}
+	return w
+}
+
+// asciiWidth returns the width of s if it is ASCII, and false otherwise.
+func asciiWidth(s string) (int, bool) {
+	w := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			return 0, false
+		}
+		if c >= 0x20 && c != 0x7F {
+			w++
+		}
+	}
+	return w, true
+}
+
+// Width returns the number of columns s takes when displayed in a terminal.
+// Characters are one column wide, except East Asian wide and fullwidth
+// characters and emoji, which take two, and control characters, combining
+// marks and other characters invisible on their own, which take none.
+// Each grapheme cluster is as wide as its first character,
+// so that combined emoji sequences count as a single emoji.
+// Ambiguous width characters are counted as narrow.
+func Width(s Str) int {
+	str := s.String()
+	if w, ok := asciiWidth(str); ok {
+		return w
+	}
+
+	w := 0
+	for len(str) > 0 {
+		n := graphemeLen(str)
+		w += graphemeWidth(str[:n])
+		str = str[n:]
+	}
+	return w
+}
+
+// TruncateWidth returns s if it is at most maxCols columns wide, as measured
+// by Width. Otherwise it returns the longest prefix of s ending on a grapheme
+// cluster boundary which leaves room for ellipsis within maxCols columns.
+// The caller appends ellipsis when the result is shorter than s.
+func TruncateWidth(s Str, maxCols int, ellipsis Str) Str {
+	str := s.String()
+	if w, ok := asciiWidth(str); ok && w <= maxCols {
+		return s
+	}
+
+	limit := maxCols - Width(ellipsis)
+	w, cut := 0, 0 // cut is the end of the longest prefix fitting limit columns
+	for i := 0; i < len(str); {
+		n := graphemeLen(str[i:])
+		w += graphemeWidth(str[i : i+n])
+		if w > maxCols {
+			return s.SliceTo(cut)
+		}
+		i += n
+		if w <= limit {
+			cut = i
+		}
+	}
+	return s
+}
+
+// PadLeft returns s preceded by as many spaces as needed to make it
+// cols columns wide, as measured by Width.
+// If s is at least cols columns wide, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func PadLeft(s Str, cols int, b *Builder) Str {
+	return pad(s, cols-Width(s), 0, b)
+}
+
+// PadRight returns s followed by as many spaces as needed to make it
+// cols columns wide, as measured by Width.
+// If s is at least cols columns wide, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func PadRight(s Str, cols int, b *Builder) Str {
+	return pad(s, 0, cols-Width(s), b)
+}
+
+// Center returns s surrounded by as many spaces as needed to make it
+// cols columns wide, as measured by Width, with the odd space if any
+// on the right.
+// If s is at least cols columns wide, it is returned without allocating.
+// Otherwise the result is finished in b, or built in a newly allocated
+// buffer if b is nil.
+func Center(s Str, cols int, b *Builder) Str {
+	n := cols - Width(s)
+	return pad(s, n/2, n-n/2, b)
+}
+
+// pad returns s with left spaces before and right spaces after it.
+func pad(s Str, left, right int, b *Builder) Str {
+	left, right = max(left, 0), max(right, 0)
+	if left+right == 0 {
+		return s
+	}
+
+	return build(b, left+s.Len+right, func(dst []byte) []byte {
+		for range left {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, s.asBytes()...)
+		for range right {
+			dst = append(dst, ' ')
+		}
+		return dst
+	})
+}
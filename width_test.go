@@ -0,0 +1,61 @@
+package str
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestWidthEastAsianWidth checks the width of every code point listed in
+// testdata/EastAsianWidth.txt, of the Unicode version of the tables: two
+// columns for wide and fullwidth characters and one for the others, except
+// marks, format and control characters, which take none.
+func TestWidthEastAsianWidth(t *testing.T) {
+	f, err := os.Open("testdata/EastAsianWidth.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		data, comment, _ := strings.Cut(sc.Text(), "#")
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+		cps, eaw, _ := strings.Cut(strings.TrimSpace(data), ";")
+		first, last, ok := strings.Cut(cps, "..")
+		if !ok {
+			last = first
+		}
+		lo, err1 := strconv.ParseUint(first, 16, 32)
+		hi, err2 := strconv.ParseUint(last, 16, 32)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("EastAsianWidth.txt:%d: invalid code points %q", line, cps)
+		}
+
+		want := 1
+		switch eaw {
+		case "W", "F":
+			want = 2
+		}
+		switch gc, _, _ := strings.Cut(strings.TrimSpace(comment), " "); gc {
+		case "Mn", "Me", "Cf", "Cc":
+			want = 0
+		case "Cs":
+			// Surrogates are not valid in UTF-8.
+			continue
+		}
+
+		for r := rune(lo); r <= rune(hi); r++ {
+			if got := Width(NewFromString(string(r))); got != want {
+				t.Errorf("EastAsianWidth.txt:%d: Width(%U) = %d, want %d", line, r, got, want)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,346 @@
+package str
+
+import (
+	"iter"
+	"strings"
+	"unicode"
+)
+
+// WrapMode selects how Wrap distributes words over lines.
+type WrapMode int
+
+const (
+	// WrapGreedy fills each line with as many words as fit.
+	WrapGreedy WrapMode = iota
+	// WrapMinRaggedness minimizes the sum of the squares of the unused
+	// widths of all lines but the last one, like the Knuth-Plass algorithm,
+	// which gives more even lines than WrapGreedy.
+	WrapMinRaggedness
+)
+
+// WrapOptions are options for Wrap.
+type WrapOptions struct {
+	Mode WrapMode
+	// BreakLongWords makes Wrap break words wider than a line on grapheme
+	// cluster boundaries instead of letting them overflow.
+	BreakLongWords bool
+	// Hyphen is appended to the parts of a broken long word but the last one.
+	Hyphen Str
+}
+
+// tabWidth is the number of columns between tab stops in indentation.
+const tabWidth = 8
+
+// wrapWord is a word of a paragraph, or a part of a broken long word.
+type wrapWord struct {
+	start, end int
+	width      int
+	// gap is the width of the space before the word.
+	gap int
+	// kept reports whether the space before the word is kept as is
+	// rather than replaced with a single space.
+	kept bool
+	// hyphen reports whether the word is a part of a broken long word
+	// which is followed by a hyphen when it ends a line.
+	hyphen bool
+}
+
+// Wrap returns an iterator over the lines of s reflowed to width columns,
+// as measured by Width.
+//
+// Lines of s end as for Lines. Paragraphs are separated by blank lines,
+// which are kept, and by lines starting with a list marker: "-", "*", "+",
+// or a number followed by "." or ")", followed by a space. Within a
+// paragraph, words are spread over lines according to opts. Runs of spaces
+// between words are kept as they are, unless a line is broken there, while
+// other white space between words, line breaks included, is replaced with
+// a single space. The indentation of the first line of a paragraph is kept
+// for the following lines, aligned after the list marker if there is one.
+//
+// Lines are slices of s whenever s holds them as they are, indentation
+// included, on one of its lines. Otherwise they are built in a buffer reused
+// by the iterator, and are only valid until the next iteration.
+// Lines do not end with a line break.
+func Wrap(s Str, width int, opts WrapOptions) iter.Seq[Str] {
+	return func(yield func(Str) bool) {
+		w := wrapper{s: s, str: s.String(), width: width, opts: opts}
+		for i := 0; i < len(w.str); {
+			start, end, next := w.line(i)
+			if isBlank(w.str[start:end]) {
+				if !yield(s.Slice(start, start)) {
+					return
+				}
+				i = next
+				continue
+			}
+
+			prefix := start + listMarkerLen(w.str[start:end])
+			for next < len(w.str) {
+				start, end, after := w.line(next)
+				if isBlank(w.str[start:end]) || listMarkerLen(w.str[start:end]) > indentLen(w.str[start:end]) {
+					break
+				}
+				next = after
+			}
+			if !w.paragraph(i, prefix, next, yield) {
+				return
+			}
+			i = next
+		}
+	}
+}
+
+// wrapper holds the state of Wrap.
+type wrapper struct {
+	s     Str
+	str   string
+	width int
+	opts  WrapOptions
+	words []wrapWord
+	// breaks holds the indexes in words of the first word of each line.
+	breaks []int
+	buf    []byte
+}
+
+// line returns the bounds of the line starting at i, without its line
+// terminator, and the start of the next line.
+func (w *wrapper) line(i int) (start, end, next int) {
+	end, n := lineEnd(w.str[i:], false)
+	return i, i + end, i + end + n
+}
+
+// isBlank reports whether line has only white space.
+func isBlank(line string) bool {
+	return indentLen(line) == len(line)
+}
+
+// indentLen returns the length of the leading white space of line.
+func indentLen(line string) int {
+	return len(line) - TrimLeftFunc(NewFromString(line), unicode.IsSpace).Len
+}
+
+// listMarkerLen returns the length of the indentation of line, followed by
+// its list marker and the spaces after it if line starts a list item.
+func listMarkerLen(line string) int {
+	i := indentLen(line)
+	j := i
+	switch {
+	case j < len(line) && (line[j] == '-' || line[j] == '*' || line[j] == '+'):
+		j++
+	case j < len(line) && '0' <= line[j] && line[j] <= '9':
+		for j < len(line) && '0' <= line[j] && line[j] <= '9' {
+			j++
+		}
+		if j == len(line) || line[j] != '.' && line[j] != ')' {
+			return i
+		}
+		j++
+	default:
+		return i
+	}
+	if j == len(line) || line[j] != ' ' && line[j] != '\t' {
+		return i
+	}
+	for j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+		j++
+	}
+	return j
+}
+
+// prefixWidth returns the width of the indentation and list marker prefix,
+// tabs advancing to the next multiple of tabWidth columns.
+func prefixWidth(prefix string) int {
+	w := 0
+	for _, c := range prefix {
+		if c == '\t' {
+			w += tabWidth - w%tabWidth
+		} else {
+			w += runeWidth(c)
+		}
+	}
+	return w
+}
+
+// paragraph yields the wrapped lines of the paragraph which starts at start,
+// has its words after prefix and ends at end.
+func (w *wrapper) paragraph(start, prefix, end int, yield func(Str) bool) bool {
+	first := w.str[start:prefix]
+	pw := prefixWidth(first)
+	avail := max(w.width-pw, 1)
+	// Continuation lines are aligned after the list marker.
+	cont := w.str[start : start+indentLen(first)]
+	if pad := pw - prefixWidth(cont); pad > 0 {
+		cont += strings.Repeat(" ", pad)
+	}
+
+	w.words = w.words[:0]
+	last := prefix
+	for off, f := range FieldsIndexed(w.s.Slice(prefix, end)) {
+		off += prefix
+		gap, kept := off-last, true
+		for _, c := range w.str[last:off] {
+			if c != ' ' {
+				gap, kept = 1, false
+				break
+			}
+		}
+		if len(w.words) == 0 {
+			gap = 0
+		}
+		w.addWord(off, off+f.Len, gap, kept, avail)
+		last = off + f.Len
+	}
+	if len(w.words) == 0 {
+		// A lone list marker.
+		return yield(TrimRightFunc(w.s.Slice(start, prefix), unicode.IsSpace))
+	}
+
+	if w.opts.Mode == WrapMinRaggedness {
+		w.balance(avail)
+	} else {
+		w.greedy(avail)
+	}
+
+	for k, from := range w.breaks {
+		to := len(w.words)
+		if k+1 < len(w.breaks) {
+			to = w.breaks[k+1]
+		}
+		linePrefix := cont
+		if k == 0 {
+			linePrefix = first
+		}
+		if !yield(w.render(linePrefix, w.words[from:to])) {
+			return false
+		}
+	}
+	return true
+}
+
+// addWord appends the word s[start:end] to w.words, broken into parts
+// fitting avail columns if needed and requested.
+func (w *wrapper) addWord(start, end, gap int, kept bool, avail int) {
+	width := Width(w.s.Slice(start, end))
+	if width <= avail || !w.opts.BreakLongWords {
+		w.words = append(w.words, wrapWord{start: start, end: end, width: width, gap: gap, kept: kept})
+		return
+	}
+
+	hyphen := Width(w.opts.Hyphen)
+	for start < end {
+		part, pw := start, 0
+		for part < end {
+			n := graphemeLen(w.str[part:end])
+			gw := graphemeWidth(w.str[part : part+n])
+			limit := avail
+			if part+n < end {
+				limit -= hyphen
+			}
+			if part > start && pw+gw > limit {
+				break
+			}
+			part += n
+			pw += gw
+		}
+		w.words = append(w.words, wrapWord{start: start, end: part, width: pw, gap: gap, kept: kept, hyphen: part < end})
+		start, gap, kept = part, 0, true
+	}
+}
+
+// greedy breaks lines before the first word which does not fit.
+func (w *wrapper) greedy(avail int) {
+	hyphen := Width(w.opts.Hyphen)
+	w.breaks = append(w.breaks[:0], 0)
+	lineWidth := w.words[0].width
+	for i := 1; i < len(w.words); i++ {
+		word := w.words[i]
+		width := lineWidth + word.gap + word.width
+		if word.hyphen {
+			width += hyphen
+		}
+		// The parts of a broken long word end their lines.
+		if w.words[i-1].hyphen || width > avail {
+			w.breaks = append(w.breaks, i)
+			lineWidth = word.width
+			continue
+		}
+		lineWidth += word.gap + word.width
+	}
+}
+
+// balance breaks lines so as to minimize the sum of the squares of the
+// unused widths of all lines but the last one.
+func (w *wrapper) balance(avail int) {
+	hyphen := Width(w.opts.Hyphen)
+	n := len(w.words)
+	// cost[i] is the minimal cost of the lines from the one starting with
+	// words[i] on, and next[i] is where the line after that one starts.
+	cost := make([]int, n+1)
+	next := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		cost[i] = -1
+		lineWidth := 0
+		for j := i; j < n; j++ {
+			word := w.words[j]
+			if j > i {
+				lineWidth += word.gap
+			}
+			lineWidth += word.width
+			width := lineWidth
+			if word.hyphen {
+				width += hyphen
+			}
+			if width > avail && j > i {
+				break
+			}
+
+			c := 0
+			if j+1 < n {
+				slack := max(avail-width, 0)
+				c = slack*slack + cost[j+1]
+			}
+			if cost[i] < 0 || c < cost[i] {
+				cost[i], next[i] = c, j+1
+			}
+			if word.hyphen {
+				// The parts of a broken long word end their lines.
+				break
+			}
+		}
+	}
+
+	w.breaks = w.breaks[:0]
+	for i := 0; i < n; i = next[i] {
+		w.breaks = append(w.breaks, i)
+	}
+}
+
+// render returns the line made of prefix and words. It is a slice of s if
+// the words are on a single line of s, preceded by prefix at its start if
+// prefix is not empty, and otherwise it is built in w.buf.
+func (w *wrapper) render(prefix string, words []wrapWord) Str {
+	first, last := words[0], words[len(words)-1]
+	lineStart := first.start - len(prefix)
+	contiguous := lineStart >= 0 && w.str[lineStart:first.start] == prefix && !last.hyphen &&
+		(prefix == "" || lineStart == 0 || w.str[lineStart-1] == '\n')
+	for _, word := range words[1:] {
+		contiguous = contiguous && word.kept
+	}
+	if contiguous {
+		return w.s.Slice(lineStart, last.end)
+	}
+
+	w.buf = append(w.buf[:0], prefix...)
+	for i, word := range words {
+		if i > 0 {
+			for range word.gap {
+				w.buf = append(w.buf, ' ')
+			}
+		}
+		w.buf = append(w.buf, w.str[word.start:word.end]...)
+	}
+	if last.hyphen {
+		w.buf = append(w.buf, w.opts.Hyphen.asBytes()...)
+	}
+	return NewFromBytes(w.buf)
+}